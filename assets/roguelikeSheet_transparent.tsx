@@ -1,4 +1,119 @@
 <?xml version="1.0" encoding="UTF-8"?>
 <tileset version="1.10" tiledversion="1.11.2" name="roguelikeSheet_transparent" tilewidth="16" tileheight="16" spacing="1" margin="1" tilecount="1680" columns="56">
  <image source="roguelikeSheet_transparent.png" width="968" height="526"/>
+ <tile id="227">
+  <animation>
+   <frame tileid="227" duration="600"/>
+   <frame tileid="0" duration="600"/>
+   <frame tileid="1" duration="600"/>
+  </animation>
+ </tile>
+ <tile id="395">
+  <properties>
+   <property name="autumn" type="int" value="795"/>
+   <property name="winter" type="int" value="459"/>
+  </properties>
+ </tile>
+ <tile id="409">
+  <properties>
+   <property name="light" type="float" value="3"/>
+   <property name="light_color" type="color" value="#ffffc070"/>
+   <property name="light_flicker" type="float" value="0.25"/>
+  </properties>
+  <animation>
+   <frame tileid="409" duration="200"/>
+   <frame tileid="410" duration="200"/>
+  </animation>
+ </tile>
+ <tile id="461">
+  <properties>
+   <property name="interaction" value="campfire"/>
+  </properties>
+ </tile>
+ <tile id="462">
+  <properties>
+   <property name="interaction" value="campfire"/>
+   <property name="light" type="float" value="4"/>
+   <property name="light_color" type="color" value="#ffffa050"/>
+   <property name="light_flicker" type="float" value="0.4"/>
+  </properties>
+  <animation>
+   <frame tileid="462" duration="250"/>
+   <frame tileid="463" duration="250"/>
+  </animation>
+ </tile>
+ <tile id="528">
+  <properties>
+   <property name="interaction" value="pick"/>
+   <property name="winter" type="int" value="529"/>
+  </properties>
+ </tile>
+ <tile id="563">
+  <properties>
+   <property name="autumn" type="int" value="795"/>
+   <property name="winter" type="int" value="459"/>
+  </properties>
+ </tile>
+ <tile id="573">
+  <properties>
+   <property name="autumn" type="int" value="574"/>
+   <property name="winter" type="int" value="531"/>
+  </properties>
+ </tile>
+ <tile id="574">
+  <properties>
+   <property name="winter" type="int" value="531"/>
+  </properties>
+ </tile>
+ <tile id="575">
+  <properties>
+   <property name="autumn" type="int" value="574"/>
+   <property name="winter" type="int" value="531"/>
+  </properties>
+ </tile>
+ <tile id="627">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+   <property name="autumn" type="int" value="795"/>
+   <property name="winter" type="int" value="459"/>
+  </properties>
+ </tile>
+ <tile id="629">
+  <properties>
+   <property name="autumn" type="int" value="630"/>
+   <property name="winter" type="int" value="643"/>
+  </properties>
+ </tile>
+ <tile id="630">
+  <properties>
+   <property name="winter" type="int" value="643"/>
+  </properties>
+ </tile>
+ <tile id="631">
+  <properties>
+   <property name="autumn" type="int" value="630"/>
+   <property name="winter" type="int" value="643"/>
+  </properties>
+ </tile>
+ <tile id="731">
+  <properties>
+   <property name="autumn" type="int" value="795"/>
+   <property name="winter" type="int" value="459"/>
+  </properties>
+ </tile>
+ <tile id="1117">
+  <properties>
+   <property name="interaction" value=""/>
+  </properties>
+ </tile>
+ <tile id="1230">
+  <properties>
+   <property name="interaction" value="mine"/>
+  </properties>
+ </tile>
+ <tile id="1231">
+  <properties>
+   <property name="interaction" value="mine"/>
+  </properties>
+ </tile>
 </tileset>
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"image"
+	"log"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/lafriks/go-tiled"
+)
+
+// tileAtlas turns Tiled tiles into images. Tileset images are loaded on first
+// use from paths relative to the tileset file, so external .tsx tilesets can
+// live in any directory. Both single-image tilesets and image collections are
+// supported.
+type tileAtlas struct {
+	images   map[string]*ebiten.Image // by resolved path, nil if loading failed
+	anims    map[*tiled.Tileset]map[uint32][]*tiled.AnimationFrame
+	variants map[variantKey]map[uint32]uint32
+}
+
+type variantKey struct {
+	ts     *tiled.Tileset
+	season string
+}
+
+func newTileAtlas() *tileAtlas {
+	return &tileAtlas{
+		images:   make(map[string]*ebiten.Image),
+		anims:    make(map[*tiled.Tileset]map[uint32][]*tiled.AnimationFrame),
+		variants: make(map[variantKey]map[uint32]uint32),
+	}
+}
+
+// load returns the image at path, loading it once.
+func (a *tileAtlas) load(path string) *ebiten.Image {
+	if img, ok := a.images[path]; ok {
+		return img
+	}
+	img, _, err := ebitenutil.NewImageFromFile(path)
+	if err != nil {
+		log.Printf("failed to load tileset image %s: %v", path, err)
+		img = nil
+	}
+	a.images[path] = img
+	return img
+}
+
+// tile returns the image of a tile by its local ID, or nil.
+func (a *tileAtlas) tile(ts *tiled.Tileset, id uint32) *ebiten.Image {
+	if ts.Image == nil || ts.Image.Source == "" {
+		// Image collection: every tile has its own picture
+		tt, err := ts.GetTilesetTile(id)
+		if err != nil || tt.Image == nil {
+			return nil
+		}
+		return a.load(ts.GetFileFullPath(tt.Image.Source))
+	}
+	img := a.load(ts.GetFileFullPath(ts.Image.Source))
+	if img == nil {
+		return nil
+	}
+	columns := ts.Columns
+	if columns == 0 {
+		columns = (ts.Image.Width - ts.Margin*2 + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+	}
+	col, row := int(id)%columns, int(id)/columns
+	x := ts.Margin + col*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + row*(ts.TileHeight+ts.Spacing)
+	return img.SubImage(image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight)).(*ebiten.Image)
+}
+
+// animation returns the Tiled animation frames of a tile, if any.
+func (a *tileAtlas) animation(ts *tiled.Tileset, id uint32) []*tiled.AnimationFrame {
+	anims, ok := a.anims[ts]
+	if !ok {
+		anims = make(map[uint32][]*tiled.AnimationFrame)
+		for _, tt := range ts.Tiles {
+			if len(tt.Animation) > 0 {
+				anims[tt.ID] = tt.Animation
+			}
+		}
+		a.anims[ts] = anims
+	}
+	return anims[id]
+}
+
+// animated reports whether a tile plays an animation.
+func (a *tileAtlas) animated(ts *tiled.Tileset, id uint32) bool {
+	return len(a.animation(ts, id)) > 0
+}
+
+// variant returns the tile drawn in place of id in a season. A tile names
+// its look in a season with a property of that name holding the ID of
+// another tile in the same tileset, e.g. winter=459; without one it looks
+// the same all year.
+func (a *tileAtlas) variant(ts *tiled.Tileset, id uint32, season string) uint32 {
+	key := variantKey{ts, season}
+	vars, ok := a.variants[key]
+	if !ok {
+		vars = make(map[uint32]uint32)
+		for _, tt := range ts.Tiles {
+			if v := tt.Properties.Get(season); len(v) > 0 {
+				if to, err := strconv.ParseUint(v[0], 10, 32); err == nil {
+					vars[tt.ID] = uint32(to)
+				}
+			}
+		}
+		a.variants[key] = vars
+	}
+	if to, ok := vars[id]; ok {
+		return to
+	}
+	return id
+}
+
+// frame returns the image of a tile at ms milliseconds of game time, playing
+// the tile's Tiled animation if it has one.
+func (a *tileAtlas) frame(ts *tiled.Tileset, id uint32, ms int) *ebiten.Image {
+	if frames := a.animation(ts, id); len(frames) > 0 {
+		total := 0
+		for _, f := range frames {
+			total += int(f.Duration)
+		}
+		if total > 0 {
+			t := ms % total
+			for _, f := range frames {
+				if t < int(f.Duration) {
+					id = f.TileID
+					break
+				}
+				t -= int(f.Duration)
+			}
+		}
+	}
+	return a.tile(ts, id)
+}
+
+// tileGeoM places a map tile inside its grid cell, in map pixels relative to
+// the cell's top-left corner. Like Tiled it applies the diagonal, then the
+// horizontal, then the vertical flip, and lines the tile up with the bottom
+// of the cell so tiles taller than the grid stick out upwards.
+func tileGeoM(tile *tiled.LayerTile, img *ebiten.Image, cellH int) ebiten.GeoM {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	var geo ebiten.GeoM
+	if tile.DiagonalFlip {
+		// Swap the axes: mirror along the top-left to bottom-right diagonal
+		geo.SetElement(0, 0, 0)
+		geo.SetElement(0, 1, 1)
+		geo.SetElement(1, 0, 1)
+		geo.SetElement(1, 1, 0)
+		w, h = h, w
+	}
+	if tile.HorizontalFlip {
+		geo.Scale(-1, 1)
+		geo.Translate(w, 0)
+	}
+	if tile.VerticalFlip {
+		geo.Scale(1, -1)
+		geo.Translate(0, h)
+	}
+	geo.Translate(0, float64(cellH)-h)
+	if off := tile.Tileset.TileOffset; off != nil {
+		geo.Translate(float64(off.X), float64(off.Y))
+	}
+	return geo
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jmainguy/survival-game/sim"
+	"github.com/lafriks/go-tiled"
+)
+
+const (
+	chunkTiles = 16                     // chunk side in tiles
+	chunkPad   = 16                     // room around a chunk for tiles bigger than the grid
+	chunkKeep  = 2 * sim.TicksPerSecond // ticks a chunk out of view stays baked
+)
+
+// mapChunk is a chunkTiles x chunkTiles block of one layer baked into an
+// offscreen image. Animated tiles can't be baked and are drawn every frame,
+// and tiles flagged "above" are left to the depth-sorted pass.
+type mapChunk struct {
+	img      *ebiten.Image // nil when the chunk has no static tiles
+	animated []int         // layer indexes of animated tiles
+	above    []int         // layer indexes of tiles sorted with characters
+	drawn    int           // world tick the chunk was last in view
+}
+
+type chunkKey struct {
+	layer  *tiled.Layer
+	cx, cy int
+}
+
+// chunkCache keeps baked chunks of the layers in view. A chunk is re-baked
+// only after one of its tiles changes or the season turns, and freed once it
+// has been out of view for chunkKeep ticks of the world clock, so walking
+// across a big map or leaving it doesn't pile up images. Counting ticks
+// rather than frames keeps that time the same on any display.
+type chunkCache struct {
+	chunks map[chunkKey]*mapChunk
+	season sim.Season // whose tile variants the chunks show
+}
+
+func newChunkCache() *chunkCache {
+	return &chunkCache{chunks: make(map[chunkKey]*mapChunk)}
+}
+
+// invalidate drops the chunk holding a tile, see sim.World.OnTileChange.
+func (c *chunkCache) invalidate(m *tiled.Map, layer *tiled.Layer, idx int) {
+	x, y := idx%m.Width, idx/m.Width
+	c.drop(chunkKey{layer, x / chunkTiles, y / chunkTiles})
+}
+
+func (c *chunkCache) drop(key chunkKey) {
+	if ch := c.chunks[key]; ch != nil && ch.img != nil {
+		ch.img.Deallocate()
+	}
+	delete(c.chunks, key)
+}
+
+// evict frees the chunks that have been out of view for a while. It is
+// called once per frame after the layers are drawn.
+func (c *chunkCache) evict(tick int) {
+	for key, ch := range c.chunks {
+		if tick-ch.drawn > chunkKeep {
+			c.drop(key)
+		}
+	}
+}
+
+// setSeason drops every chunk when the season turns, so they are baked again
+// with the new season's tile variants.
+func (c *chunkCache) setSeason(s sim.Season) {
+	if s == c.season {
+		return
+	}
+	for key := range c.chunks {
+		c.drop(key)
+	}
+	c.season = s
+}
+
+// tileID is the tile of the tileset drawn for a map tile this season.
+func (g *Game) tileID(tile *tiled.LayerTile) uint32 {
+	return g.atlas.variant(tile.Tileset, tile.ID, g.chunks.season.String())
+}
+
+// bakeChunk draws the static tiles of one chunk into a fresh image.
+func (g *Game) bakeChunk(m *tiled.Map, key chunkKey) *mapChunk {
+	ch := &mapChunk{}
+	size := chunkTiles*tileSize + 2*chunkPad
+	for y := key.cy * chunkTiles; y < min((key.cy+1)*chunkTiles, m.Height); y++ {
+		for x := key.cx * chunkTiles; x < min((key.cx+1)*chunkTiles, m.Width); x++ {
+			idx := y*m.Width + x
+			tile := key.layer.Tiles[idx]
+			if tile == nil || tile.Tileset == nil {
+				continue
+			}
+			if sim.DrawsAbove(key.layer, idx) {
+				ch.above = append(ch.above, idx)
+				continue
+			}
+			id := g.tileID(tile)
+			if g.atlas.animated(tile.Tileset, id) {
+				ch.animated = append(ch.animated, idx)
+				continue
+			}
+			tileImg := g.atlas.tile(tile.Tileset, id)
+			if tileImg == nil {
+				continue
+			}
+			if ch.img == nil {
+				ch.img = ebiten.NewImage(size, size)
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM = tileGeoM(tile, tileImg, m.TileHeight)
+			op.GeoM.Translate(float64((x-key.cx*chunkTiles)*tileSize+chunkPad), float64((y-key.cy*chunkTiles)*tileSize+chunkPad))
+			ch.img.DrawImage(tileImg, op)
+		}
+	}
+	return ch
+}
+
+// drawLayer draws the chunks of a layer that overlap the viewport and adds
+// the layer's visible "above" tiles to sprites.
+func (g *Game) drawLayer(screen *ebiten.Image, layer *tiled.Layer, camX, camY int, sprites []depthSprite) []depthSprite {
+	m := g.world.Map
+	chunkPx := chunkTiles * tileSize * scale
+	pad := chunkPad * scale // a neighbouring chunk's big tiles may reach in
+	cx0, cy0 := max((camX-pad)/chunkPx, 0), max((camY-pad)/chunkPx, 0)
+	cx1 := min((camX+g.viewW+pad)/chunkPx, (m.Width-1)/chunkTiles)
+	cy1 := min((camY+g.viewH+pad)/chunkPx, (m.Height-1)/chunkTiles)
+	for cy := cy0; cy <= cy1; cy++ {
+		for cx := cx0; cx <= cx1; cx++ {
+			key := chunkKey{layer, cx, cy}
+			ch := g.chunks.chunks[key]
+			if ch == nil {
+				ch = g.bakeChunk(m, key)
+				g.chunks.chunks[key] = ch
+			}
+			ch.drawn = g.world.Tick
+			if ch.img != nil {
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Translate(float64(cx*chunkTiles*tileSize-chunkPad), float64(cy*chunkTiles*tileSize-chunkPad))
+				op.GeoM.Scale(scale, scale)
+				op.GeoM.Translate(float64(-camX), float64(-camY))
+				screen.DrawImage(ch.img, op)
+			}
+			for _, idx := range ch.animated {
+				g.drawTile(screen, layer.Tiles[idx], idx, camX, camY)
+			}
+			for _, idx := range ch.above {
+				tile := layer.Tiles[idx]
+				sprites = append(sprites, depthSprite{
+					foot: (idx/m.Width + 1) * tileSize,
+					draw: func() { g.drawTile(screen, tile, idx, camX, camY) },
+				})
+			}
+		}
+	}
+	return sprites
+}
+
+// drawTile draws a single map tile in its seasonal look, animated if it has
+// frames.
+func (g *Game) drawTile(screen *ebiten.Image, tile *tiled.LayerTile, idx, camX, camY int) {
+	m := g.world.Map
+	tileImg := g.atlas.frame(tile.Tileset, g.tileID(tile), g.animMillis())
+	if tileImg == nil {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM = tileGeoM(tile, tileImg, m.TileHeight)
+	op.GeoM.Translate(float64(idx%m.Width*tileSize), float64(idx/m.Width*tileSize))
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(float64(-camX), float64(-camY))
+	screen.DrawImage(tileImg, op)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "sort"
+
+// depthSprite is drawn in the Y-sorted pass after the ground: characters,
+// tile objects and map tiles flagged "above". Whatever stands further down
+// the screen is drawn later, so it covers what stands behind it.
+type depthSprite struct {
+	foot int // map Y of the bottom edge the sprite stands on
+	draw func()
+}
+
+// drawSorted draws sprites back to front. Ties keep the order they were
+// added in, so characters end up on top of tiles sharing their row.
+func drawSorted(sprites []depthSprite) {
+	sort.SliceStable(sprites, func(i, j int) bool {
+		return sprites[i].foot < sprites[j].foot
+	})
+	for _, s := range sprites {
+		s.draw()
+	}
+}
@@ -5,586 +5,137 @@ import (
 	"image/color"
 	"log"
 	"math"
-	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jmainguy/survival-game/sim"
 )
 
 func (g *Game) Update() error {
-	if g.gameOver {
-		// Restart game on any key press or mouse click
-		if ebiten.IsKeyPressed(ebiten.KeySpace) || ebiten.IsKeyPressed(ebiten.KeyEnter) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-			g.restart()
-		}
+	if g.title != nil {
+		g.updateTitle()
 		return nil
 	}
-
-	// Find and cache all collidable layers except "Base ground"
-	collidableLayers := map[string]bool{}
-	for _, layer := range g.mapData.Layers {
-		if layer.Name != "Base ground" && layer.Visible {
-			collidableLayers[layer.Name] = true
-		}
-	}
-	// Find the "Trees" layer if not already cached
-	if g.treesLayer == nil {
-		for _, layer := range g.mapData.Layers {
-			if layer.Name == "Trees" {
-				g.treesLayer = layer
-				break
-			}
-		}
-	}
-
-	const moveSpeed = 1
-
-	// --- NPC movement and animation logic ---
-	for _, npc := range g.npcs {
-		// If chatting and this is the chatting NPC, freeze movement
-		if g.chatting && g.chatNPC == npc {
-			npc.moving = false
-			continue
-		}
-		// If chatting, freeze all NPCs
-		if g.chatting {
-			npc.moving = false
-			continue
-		}
-		if !npc.moving {
-			npc.moveTick++
-			if npc.moveTick > 30+rand.Intn(30) {
-				npc.moveTick = 0
-				dirs := []struct{ dx, dy, dir int }{
-					{0, -tileSize, 3}, // up
-					{0, tileSize, 0},  // down
-					{-tileSize, 0, 2}, // left
-					{tileSize, 0, 1},  // right
-				}
-				rand.Shuffle(len(dirs), func(i, j int) { dirs[i], dirs[j] = dirs[j], dirs[i] })
-				for _, d := range dirs {
-					newX := npc.pos.X + d.dx
-					newY := npc.pos.Y + d.dy
-					// Check map bounds
-					if newX < 0 || newY < 0 || newX > g.mapData.Width*tileSize-tileSize/4 || newY > g.mapData.Height*tileSize-tileSize/4 {
-						continue
-					}
-					// Check collision with all collidable layers except "Base ground"
-					blocked := false
-					for _, layer := range g.mapData.Layers {
-						if layer.Name == "Base ground" || !layer.Visible {
-							continue
-						}
-						npcSize := tileSize
-						npcOffset := (tileSize - npcSize) / 2
-						centerX := newX + npcOffset + npcSize/2
-						centerY := newY + npcOffset + npcSize/2
-						tileX := centerX / tileSize
-						tileY := centerY / tileSize
-						if tileX >= 0 && tileX < g.mapData.Width && tileY >= 0 && tileY < g.mapData.Height {
-							tile := layer.Tiles[tileY*g.mapData.Width+tileX]
-							if tile != nil && tile.Tileset != nil {
-								blocked = true
-								break
-							}
-						}
-					}
-					// Check collision with all other characters (player and NPCs)
-					npcRect := image.Rect(newX, newY, newX+tileSize, newY+tileSize)
-					playerRect := image.Rect(g.playerPos.X, g.playerPos.Y, g.playerPos.X+tileSize, g.playerPos.Y+tileSize)
-					if npcRect.Overlaps(playerRect) {
-						blocked = true
-					}
-					for _, other := range g.npcs {
-						if other == npc {
-							continue
-						}
-						otherRect := image.Rect(other.pos.X, other.pos.Y, other.pos.X+tileSize, other.pos.Y+tileSize)
-						if npcRect.Overlaps(otherRect) {
-							blocked = true
-							break
-						}
-					}
-					if !blocked {
-						npc.target = image.Point{X: newX, Y: newY}
-						npc.dir = d.dir
-						npc.moving = true
-						break
-					}
-				}
-			}
-		} else {
-			// Move smoothly toward target
-			step := 2
-			dx := npc.target.X - npc.pos.X
-			dy := npc.target.Y - npc.pos.Y
-			if dx != 0 {
-				if abs(dx) < step {
-					npc.pos.X = npc.target.X
-				} else {
-					npc.pos.X += step * sign(dx)
-				}
-			}
-			if dy != 0 {
-				if abs(dy) < step {
-					npc.pos.Y = npc.target.Y
-				} else {
-					npc.pos.Y += step * sign(dy)
-				}
-			}
-			if npc.pos == npc.target {
-				npc.moving = false
-			}
-		}
-		// Animate NPC sprite
-		npc.animTick++
-		if npc.animTick > 15 {
-			npc.anim = (npc.anim + 1) % 4
-			npc.animTick = 0
-		}
-	}
-
-	// --- In-game time and bar drain logic ---
-	const realSecondsPerGameMinute = 10.0 / 60.0 // 10 real min per game hour, so 10/60 per game min
-	now := time.Now()
-	if g.lastTick.IsZero() {
-		g.lastTick = now
-	}
-	elapsed := now.Sub(g.lastTick).Seconds()
-	gameMinAdvance := int(elapsed / realSecondsPerGameMinute)
-	if gameMinAdvance > 0 {
-		g.gameMinutes = (g.gameMinutes + gameMinAdvance) % (24 * 60)
-		g.lastTick = g.lastTick.Add(time.Duration(float64(gameMinAdvance) * realSecondsPerGameMinute * float64(time.Second)))
+	g.world.Step(readInput())
+	if msg := g.world.TakeNotice(); msg != "" {
+		g.showStatus(msg)
 	}
+	g.updateSaves()
+	g.updateMusic()
+	g.updateWeather()
+	return nil
+}
 
-	// Drain 50% from hunger/social every 24 in-game hours, but smoothly
-	// That is, every in-game minute, drain (0.5 / 1440) from each
-	drainPerMinute := 0.5 / 1440.0
-	if g.lastDrain == 0 {
-		g.lastDrain = g.gameMinutes
-	}
-	for g.lastDrain != g.gameMinutes {
-		g.hunger -= drainPerMinute
-		g.social -= drainPerMinute
-		if g.hunger < 0 {
-			g.hunger = 0
-		}
-		if g.social < 0 {
-			g.social = 0
-		}
-		g.lastDrain = (g.lastDrain + 1) % (24 * 60)
+// Quick save on F5 and write the autosave slot when the world asks for it
+func (g *Game) updateSaves() {
+	if g.statusTicks > 0 {
+		g.statusTicks--
 	}
-
-	// --- NPC interaction logic ---
-	if g.chatting {
-		const chatInputDelay = 200 * time.Millisecond
-		now := time.Now()
-		// Always allow a "Goodbye" choice if there are no choices or all choices are terminal
-		if g.convNode != nil && len(g.convNode.Choices) == 0 {
-			g.convNode.Choices = []ConversationChoice{
-				{Text: "Goodbye", Next: nil},
-			}
-			g.chatChoice = 0
-		}
-		if len(g.convNode.Choices) > 0 {
-			// Only allow input if enough time has passed since last choice
-			if ebiten.IsKeyPressed(ebiten.KeyArrowUp) && now.Sub(g.lastChoiceTime) > chatInputDelay {
-				g.chatChoice--
-				if g.chatChoice < 0 {
-					g.chatChoice = len(g.convNode.Choices) - 1
-				}
-				g.lastChoiceTime = now
-			}
-			if ebiten.IsKeyPressed(ebiten.KeyArrowDown) && now.Sub(g.lastChoiceTime) > chatInputDelay {
-				g.chatChoice++
-				if g.chatChoice >= len(g.convNode.Choices) {
-					g.chatChoice = 0
-				}
-				g.lastChoiceTime = now
-			}
-			if ebiten.IsKeyPressed(ebiten.KeySpace) && now.Sub(g.lastChoiceTime) > chatInputDelay {
-				choice := g.convNode.Choices[g.chatChoice]
-				// --- Tree removal logic: remove tree tile immediately after "Okay" is chosen ---
-				if g.pendingTreeLayer != nil && g.convNode.Text == "You cut down the tree." && choice.Text == "Okay" {
-					g.pendingTreeLayer.Tiles[g.pendingTreeTileIdx] = nil // Remove tree tile from layer
-					g.pendingTreeLayer = nil
-					g.pendingTreeTileIdx = 0
-					g.addToInventory("Wood", 10)
-				}
-				// --- Fishing logic: add fish if caught ---
-				if g.convNode.Text == "You cast your line... (Nothing bites yet!)" && choice.Text == "Okay" {
-					// If you want to only add fish when something is caught, change the text above
-					// For now, add fish for demonstration
-					g.addToInventory("Fish", 1)
-				}
-				if choice.Text == "Goodbye" || choice.Next == nil {
-					g.chatting = false
-					g.chatNPC = nil
-					g.convNode = nil
-					g.lastChatEnd = now
-					// Social bar +10% when talking to NPC
-					g.social += 0.10
-					if g.social > 1.0 {
-						g.social = 1.0
-					}
-				} else if choice.Next != nil {
-					g.convNode = choice.Next
-					g.chatChoice = 0
-				}
-				g.lastChoiceTime = now
-			}
-		}
-		return nil // Don't allow movement while chatting
+	autosave := g.world.AutosaveDue()
+	if g.world.GameOver {
+		return // a dead world would die again as soon as it was loaded
 	}
-
-	const inventoryInputDelay = 200 * time.Millisecond
-	const inventoryBlockAfterAction = time.Second
-
-	now = time.Now()
-
-	// Prevent inventory open for 1s after tree cut or fishing
-	blockInventory := false
-	if now.Sub(g.lastChatEnd) < inventoryBlockAfterAction {
-		blockInventory = true
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		g.saveSlot(g.slot)
 	}
-
-	// --- Inventory open/close logic with delay and block after action ---
-	if ebiten.IsKeyPressed(ebiten.KeySpace) && !g.inventoryOpen && now.Sub(g.lastInventoryTime) > inventoryInputDelay && !blockInventory {
-		// Only open inventory if not interacting with NPC, water, trees, or doors
-		playerSize := tileSize
-		playerOffset := (tileSize - playerSize) / 2
-		centerX := g.playerPos.X + playerOffset + playerSize/2
-		centerY := g.playerPos.Y + playerOffset + playerSize/2
-		tileX := centerX / tileSize
-		tileY := centerY / tileSize
-		interactX, interactY := tileX, tileY
-		switch g.playerDir {
-		case 0:
-			interactY++
-		case 1:
-			interactX--
-		case 2:
-			interactX++
-		case 3:
-			interactY--
-		}
-		for _, npc := range g.npcs {
-			if isFacingNPC(g, npc) {
-				goto skipInventoryOpen
-			}
-		}
-		for _, layer := range g.mapData.Layers {
-			if (layer.Name == "Water" || layer.Name == "Trees" || layer.Name == "Doors") &&
-				interactX >= 0 && interactX < g.mapData.Width && interactY >= 0 && interactY < g.mapData.Height {
-				tile := layer.Tiles[interactY*g.mapData.Width+interactX]
-				if tile != nil && tile.Tileset != nil {
-					goto skipInventoryOpen
-				}
-			}
-		}
-		g.inventoryOpen = true
-		g.lastInventoryTime = now
-		return nil
+	if autosave {
+		g.saveSlot(sim.AutosaveSlot)
 	}
-skipInventoryOpen:
+}
 
-	// Inventory interaction: close with space, or cook/eat fish
-	if g.inventoryOpen {
-		// Only allow input if enough time has passed since last inventory action
-		if ebiten.IsKeyPressed(ebiten.KeySpace) && now.Sub(g.lastInventoryTime) > inventoryInputDelay {
-			g.inventoryOpen = false
-			g.lastInventoryTime = now
-			return nil
-		}
-		// Cook fish: press 'C'
-		if ebiten.IsKeyPressed(ebiten.KeyC) && now.Sub(g.lastInventoryTime) > inventoryInputDelay {
-			if g.hasItem("Fish", 1) && g.hasItem("Wood", 1) {
-				g.removeItem("Fish", 1)
-				g.removeItem("Wood", 1)
-				g.addToInventory("Cooked Fish", 1)
-				// Block inventory open for 1s after cooking
-				g.lastChatEnd = now
-			}
-			g.lastInventoryTime = now
-			return nil
-		}
-		// Eat cooked fish: press 'E'
-		if ebiten.IsKeyPressed(ebiten.KeyE) && now.Sub(g.lastInventoryTime) > inventoryInputDelay {
-			if g.hasItem("Cooked Fish", 1) {
-				g.removeItem("Cooked Fish", 1)
-				// Add 10% to hunger bar
-				g.hunger += 0.10
-				if g.hunger > 1.0 {
-					g.hunger = 1.0
-				}
-			}
-			g.lastInventoryTime = now
-			return nil
-		}
-		return nil
+func (g *Game) saveSlot(slot string) {
+	if err := g.world.SaveSlot(g.saveDir, slot); err != nil {
+		log.Printf("failed to save slot %s: %v", slot, err)
+		g.showStatus("Save failed!")
+		return
 	}
+	g.showStatus("Saved to " + slot)
+}
 
-	// Player movement logic
-	if !g.chatting {
-		newPos := g.playerPos
-		if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
-			newPos.X -= moveSpeed
-			g.playerDir = 1 // left
-			g.moving = true
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
-			newPos.X += moveSpeed
-			g.playerDir = 2 // right
-			g.moving = true
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
-			newPos.Y -= moveSpeed
-			g.playerDir = 3 // up
-			g.moving = true
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
-			newPos.Y += moveSpeed
-			g.playerDir = 0 // down
-			g.moving = true
-		}
-
-		// Clamp intended position to map bounds
-		maxX := g.mapData.Width*tileSize - tileSize
-		maxY := g.mapData.Height*tileSize - tileSize
-		if newPos.X < 0 {
-			newPos.X = 0
-		}
-		if newPos.Y < 0 {
-			newPos.Y = 0
-		}
-		if newPos.X > maxX {
-			newPos.X = maxX
-		}
-		if newPos.Y > maxY {
-			newPos.Y = maxY
-		}
-
-		// Collision check with all collidable layers except "Base ground"
-		blocked := false
-		for _, layer := range g.mapData.Layers {
-			if !collidableLayers[layer.Name] {
-				continue
-			}
-			playerSize := tileSize
-			playerOffset := (tileSize - playerSize) / 2
-			centerX := newPos.X + playerOffset + playerSize/2
-			centerY := newPos.Y + playerOffset + playerSize/2
-			tileX := centerX / tileSize
-			tileY := centerY / tileSize
-			if tileX >= 0 && tileX < g.mapData.Width && tileY >= 0 && tileY < g.mapData.Height {
-				tile := layer.Tiles[tileY*g.mapData.Width+tileX]
-				if tile != nil && tile.Tileset != nil {
-					blocked = true
-					break
-				}
-			}
-		}
-		// Collision with all characters (player can't walk through NPCs or other players)
-		playerRect := image.Rect(newPos.X, newPos.Y, newPos.X+tileSize, newPos.Y+tileSize)
-		for _, npc := range g.npcs {
-			npcRect := image.Rect(npc.pos.X, npc.pos.Y, npc.pos.X+tileSize, npc.pos.Y+tileSize)
-			if playerRect.Overlaps(npcRect) {
-				blocked = true
-				break
-			}
-		}
-
-		if !blocked {
-			g.playerPos = newPos
-		}
-
-		// Animation: advance frame if moving, else reset to stand
-		if g.moving {
-			g.playerAnimTick++
-			if g.playerAnimTick > 10 {
-				g.playerAnim = (g.playerAnim + 1) % 4 // 4 frames: 0-3
-				g.playerAnimTick = 0
-			}
-		} else {
-			g.playerAnim = 0
-			g.playerAnimTick = 0
-		}
-
-		// Check for NPC or layer interaction
-		if ebiten.IsKeyPressed(ebiten.KeySpace) && !g.chatting {
-			// Prevent chat if less than 1 second since last chat ended
-			if time.Since(g.lastChatEnd) < time.Second {
-				return nil
-			}
-			// --- NPC interaction ---
-			for _, npc := range g.npcs {
-				if isFacingNPC(g, npc) {
-					g.lastChoiceTime = time.Now()
-					g.chatting = true
-					g.chatNPC = npc
-					g.chatChoice = 0
-					// Face each other
-					if g.playerPos.X < npc.pos.X {
-						g.playerDir = 2 // right
-						npc.dir = 1     // npc faces left
-					} else if g.playerPos.X > npc.pos.X {
-						g.playerDir = 1 // left
-						npc.dir = 2     // npc faces right
-					} else if g.playerPos.Y < npc.pos.Y {
-						g.playerDir = 0 // down
-						npc.dir = 3     // npc faces up
-					} else if g.playerPos.Y > npc.pos.Y {
-						g.playerDir = 3 // up
-						npc.dir = 0     // npc faces down
-					}
-					// Start conversation
-					if g.conversations == nil {
-						g.initConversations()
-					}
-					g.convNode = g.conversations[npc.name]
-					return nil
-				}
-			}
-			// --- Layer interaction (Water/Trees) ---
-			playerSize := tileSize
-			playerOffset := (tileSize - playerSize) / 2
-			centerX := g.playerPos.X + playerOffset + playerSize/2
-			centerY := g.playerPos.Y + playerOffset + playerSize/2
-			tileX := centerX / tileSize
-			tileY := centerY / tileSize
-
-			// Always check all directions (not just up/down)
-			interactX, interactY := tileX, tileY
-			switch g.playerDir {
-			case 0: // down
-				interactY++
-			case 1: // right
-				interactX--
-			case 2: // left
-				interactX++
-			case 3: // up
-				interactY--
-			}
-
-			// Water interaction
-			for _, layer := range g.mapData.Layers {
-				if layer.Name == "Water" {
-					if interactX >= 0 && interactX < g.mapData.Width && interactY >= 0 && interactY < g.mapData.Height {
-						tile := layer.Tiles[interactY*g.mapData.Width+interactX]
-						if tile != nil && tile.Tileset != nil {
-							g.chatting = true
-							g.chatNPC = nil
-							g.chatChoice = 0
-							g.convNode = &ConversationNode{
-								Text: "You are at the water. Would you like to fish?",
-								Choices: []ConversationChoice{
-									{Text: "Yes, fish!", Next: &ConversationNode{
-										Text: "You cast your line... (Nothing bites yet!)",
-										Choices: []ConversationChoice{
-											{Text: "Okay", Next: nil},
-										},
-									}},
-									{Text: "No, walk away.", Next: nil},
-								},
-							}
-							g.lastChoiceTime = time.Now()
-							return nil
-						}
-					}
-				}
-			}
-			// Tree interaction
-			for _, layer := range g.mapData.Layers {
-				if layer.Name == "Trees" {
-					if interactX >= 0 && interactX < g.mapData.Width && interactY >= 0 && interactY < g.mapData.Height {
-						tileIdx := interactY*g.mapData.Width + interactX
-						tile := layer.Tiles[tileIdx]
-						if tile != nil && tile.Tileset != nil {
-							g.chatting = true
-							g.chatNPC = nil
-							g.chatChoice = 0
-							g.convNode = &ConversationNode{
-								Text: "You are facing a tree. Cut it down?",
-								Choices: []ConversationChoice{
-									{Text: "Yes, cut it down.", Next: &ConversationNode{
-										Text: "You cut down the tree.",
-										Choices: []ConversationChoice{
-											{Text: "Okay", Next: nil},
-										},
-									}},
-									{Text: "No, leave it.", Next: nil},
-								},
-							}
-							g.lastChoiceTime = time.Now()
-							g.pendingTreeLayer = layer
-							g.pendingTreeTileIdx = tileIdx
-							return nil
-						}
-					}
-				}
-			}
-		}
-	}
+func (g *Game) showStatus(msg string) {
+	g.statusMsg = msg
+	g.statusTicks = 2 * sim.TicksPerSecond
+}
 
-	// Handle music playback: if not playing, play next random mp3
-	if g.musicPlayer == nil || !g.musicPlayer.IsPlaying() {
-		next := nextMusicFile(&g.musicFiles, &g.musicPlayed)
-		if next != "" && g.audioContext != nil {
-			// Close previous player if exists
-			if g.musicPlayer != nil {
-				g.musicPlayer.Close()
-				g.musicPlayer = nil
-			}
-			// Open file for the entire duration of playback
-			f, err := os.Open(next)
-			if err == nil {
-				stream, err := mp3.DecodeWithSampleRate(44100, f)
-				if err == nil {
-					player, err := g.audioContext.NewPlayer(stream)
-					if err == nil {
-						player.SetVolume(0.5)
-						player.Play()
-						g.musicPlayer = player
-						// Keep file open for the duration of playback
-						go func(p *audio.Player, file *os.File) {
-							for p.IsPlaying() {
-								// Sleep a bit, then check again
-								time.Sleep(100 * time.Millisecond)
-							}
-							file.Close()
-						}(player, f)
-					} else {
-						f.Close()
-					}
-				} else {
-					f.Close()
-				}
-			}
-		}
+// readInput polls the keyboard and mouse into a simulation input snapshot.
+func readInput() sim.Input {
+	return sim.Input{
+		Left:    ebiten.IsKeyPressed(ebiten.KeyArrowLeft),
+		Right:   ebiten.IsKeyPressed(ebiten.KeyArrowRight),
+		Up:      ebiten.IsKeyPressed(ebiten.KeyArrowUp),
+		Down:    ebiten.IsKeyPressed(ebiten.KeyArrowDown),
+		Action:  ebiten.IsKeyPressed(ebiten.KeySpace),
+		Back:    ebiten.IsKeyPressed(ebiten.KeyEscape),
+		Craft:   ebiten.IsKeyPressed(ebiten.KeyEnter),
+		Eat:     ebiten.IsKeyPressed(ebiten.KeyE),
+		Use:     ebiten.IsKeyPressed(ebiten.KeyF),
+		Build:   ebiten.IsKeyPressed(ebiten.KeyB),
+		Next:    ebiten.IsKeyPressed(ebiten.KeyTab),
+		Restart: ebiten.IsKeyPressed(ebiten.KeySpace) || ebiten.IsKeyPressed(ebiten.KeyEnter) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
 	}
+}
 
-	return nil
+// Handle music playback: if not playing, play next random mp3
+func (g *Game) updateMusic() {
+	if g.musicPlayer != nil && g.musicPlayer.IsPlaying() {
+		return
+	}
+	next := nextMusicFile(&g.musicFiles, &g.musicPlayed)
+	if next == "" || g.audioContext == nil {
+		return
+	}
+	// Close previous player if exists
+	if g.musicPlayer != nil {
+		g.musicPlayer.Close()
+		g.musicPlayer = nil
+	}
+	// Open file for the entire duration of playback
+	f, err := os.Open(next)
+	if err != nil {
+		return
+	}
+	stream, err := mp3.DecodeWithSampleRate(44100, f)
+	if err != nil {
+		f.Close()
+		return
+	}
+	player, err := g.audioContext.NewPlayer(stream)
+	if err != nil {
+		f.Close()
+		return
+	}
+	player.SetVolume(0.5)
+	player.Play()
+	g.musicPlayer = player
+	// Keep file open for the duration of playback
+	go func(p *audio.Player, file *os.File) {
+		for p.IsPlaying() {
+			// Sleep a bit, then check again
+			time.Sleep(100 * time.Millisecond)
+		}
+		file.Close()
+	}(player, f)
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	// Camera: center on player, clamp to map bounds
-	viewportW := g.mapData.Width * tileSize * scale / 2
-	viewportH := g.mapData.Height * tileSize * scale / 2
-	if viewportW > g.mapData.Width*tileSize*scale {
-		viewportW = g.mapData.Width * tileSize * scale
-	}
-	if viewportH > g.mapData.Height*tileSize*scale {
-		viewportH = g.mapData.Height * tileSize * scale
-	}
-	camX := g.playerPos.X*scale + (tileSize*scale)/2 - viewportW/2
-	camY := g.playerPos.Y*scale + (tileSize*scale)/2 - viewportH/2
-	maxCamX := g.mapData.Width*tileSize*scale - viewportW
-	maxCamY := g.mapData.Height*tileSize*scale - viewportH
+	if g.title != nil {
+		g.drawTitle(screen)
+		return
+	}
+	// Camera: center on player, clamp to map bounds; maps smaller than the
+	// screen are centred instead
+	viewportW, viewportH := g.viewW, g.viewH
+	camX := g.world.Player.Pos.X*scale + (tileSize*scale)/2 - viewportW/2
+	camY := g.world.Player.Pos.Y*scale + (tileSize*scale)/2 - viewportH/2
+	maxCamX := g.world.Map.Width*tileSize*scale - viewportW
+	maxCamY := g.world.Map.Height*tileSize*scale - viewportH
 	if camX < 0 {
 		camX = 0
 	}
@@ -597,109 +148,89 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	if camY > maxCamY {
 		camY = maxCamY
 	}
+	if maxCamX < 0 {
+		camX = maxCamX / 2
+	}
+	if maxCamY < 0 {
+		camY = maxCamY / 2
+	}
 
-	// Draw all visible map layers, scaled up, with camera offset
-	for _, layer := range g.mapData.Layers {
+	// Draw all visible map layers from the chunk cache, scaled up, with camera
+	// offset; tiles flagged "above" are kept for the depth-sorted pass
+	g.chunks.setSeason(g.world.Season())
+	var sprites []depthSprite
+	for _, layer := range g.world.Map.Layers {
 		if !layer.Visible {
 			continue
 		}
-		for y := 0; y < g.mapData.Height; y++ {
-			for x := 0; x < g.mapData.Width; x++ {
-				tile := layer.Tiles[y*g.mapData.Width+x]
-				if tile == nil || tile.Tileset == nil {
-					continue
-				}
-				ts := tile.Tileset
-				imgPath := ts.Image.Source
-				if imgPath != "" && imgPath[0] != '/' {
-					imgPath = "assets/" + imgPath
-				}
-				tileImg := g.tilesetImgs[imgPath]
-				if tileImg == nil {
-					continue
-				}
-				tilesPerRow := (ts.Image.Width - ts.Margin*2 + ts.Spacing) / (ts.TileWidth + ts.Spacing)
-				tileID := int(tile.ID)
-				tileX := ts.Margin + (tileID%tilesPerRow)*(ts.TileWidth+ts.Spacing)
-				tileY := ts.Margin + (tileID/tilesPerRow)*(ts.TileHeight+ts.Spacing)
-				src := image.Rect(tileX, tileY, tileX+ts.TileWidth, tileY+ts.TileHeight)
-				op := &ebiten.DrawImageOptions{}
-				op.GeoM.Scale(scale, scale)
-				op.GeoM.Translate(float64(x*tileSize*scale-camX), float64(y*tileSize*scale-camY))
-				screen.DrawImage(tileImg.SubImage(src).(*ebiten.Image), op)
-			}
-		}
+		sprites = g.drawLayer(screen, layer, camX, camY, sprites)
 	}
-	// Draw player using idle/walk sprite sheet if loaded
-	spriteW, spriteH := 32, 48 // Each frame is 32x48 pixels for 128x192 sheets (4x4)
-	var spriteSheet *ebiten.Image
-	if g.moving && g.walkSprite != nil {
-		spriteSheet = g.walkSprite
-	} else if g.idleSprite != nil {
-		spriteSheet = g.idleSprite
-	}
-	if spriteSheet != nil {
-		sx := g.playerAnim * spriteW
-		// Map playerDir to correct row in sprite sheet:
-		// 0=down (row 0), 1=right (row 2), 2=left (row 1), 3=up (row 3)
-		var animRow int
-		switch g.playerDir {
-		case 0: // down
-			animRow = 0
-		case 1: // right
-			animRow = 1
-		case 2: // left
-			animRow = 2
-		case 3: // up
-			animRow = 3
-		}
-		sy := animRow * spriteH
-		src := image.Rect(sx, sy, sx+spriteW, sy+spriteH)
+	g.chunks.evict(g.world.Tick)
 
-		playerSize := tileSize
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Scale(
-			float64(playerSize)/float64(spriteW)*scale,
-			float64(playerSize)/float64(spriteH)*scale,
-		)
-		op.GeoM.Translate(float64(g.playerPos.X*scale-camX), float64(g.playerPos.Y*scale-camY))
-		screen.DrawImage(spriteSheet.SubImage(src).(*ebiten.Image), op)
-	} else {
-		// fallback: red square
-		playerSize := tileSize / 4
-		playerImg := ebiten.NewImage(playerSize, playerSize)
-		playerImg.Fill(color.RGBA{255, 0, 0, 255})
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Scale(scale, scale)
-		op.GeoM.Translate(float64(g.playerPos.X*scale-camX), float64(g.playerPos.Y*scale-camY))
-		screen.DrawImage(playerImg, op)
+	// Draw items lying on the map
+	for _, it := range g.world.Pickups() {
+		if icon := g.itemIcon(it.Item); icon != nil {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Scale(scale, scale)
+			op.GeoM.Translate(float64(it.Pos.X*scale-camX), float64(it.Pos.Y*scale-camY))
+			screen.DrawImage(icon, op)
+		}
 	}
 
-	// Draw NPCs with animation (use same logic as enemies)
-	spriteW, spriteH = 32, 48
-	for _, npc := range g.npcs {
-		if npc.sprite != nil {
-			sx := npc.anim * spriteW
-			var animRow int
-			switch npc.dir {
-			case 0: // down
-				animRow = 0
-			case 1: // right
-				animRow = 2 // swap right to left row
-			case 2: // left
-				animRow = 1 // swap left to right row
-			case 3: // up
-				animRow = 3
+	// Tile objects placed on object layers stand on their position
+	for _, group := range g.world.Map.ObjectGroups {
+		if !group.Visible {
+			continue
+		}
+		for _, obj := range group.Objects {
+			if obj.GID == 0 || !obj.Visible {
+				continue
 			}
-			sy := animRow * spriteH
-			src := image.Rect(sx, sy, sx+spriteW, sy+spriteH)
-			op := &ebiten.DrawImageOptions{}
-			op.GeoM.Scale(float64(tileSize)/float64(spriteW)*scale, float64(tileSize)/float64(spriteH)*scale)
-			op.GeoM.Translate(float64(npc.pos.X*scale-camX), float64(npc.pos.Y*scale-camY))
-			screen.DrawImage(npc.sprite.SubImage(src).(*ebiten.Image), op)
+			tile, err := g.world.Map.TileGIDToTile(obj.GID)
+			if err != nil || tile.Tileset == nil {
+				continue
+			}
+			sprites = append(sprites, depthSprite{
+				foot: int(obj.Y),
+				draw: func() {
+					img := g.atlas.frame(tile.Tileset, g.tileID(tile), g.animMillis())
+					if img == nil {
+						return
+					}
+					op := &ebiten.DrawImageOptions{}
+					op.GeoM = tileGeoM(tile, img, 0)
+					op.GeoM.Translate(obj.X, obj.Y)
+					op.GeoM.Scale(scale, scale)
+					op.GeoM.Translate(float64(-camX), float64(-camY))
+					screen.DrawImage(img, op)
+				},
+			})
 		}
 	}
 
+	// Characters stand on the bottom edge of their tile
+	sprites = append(sprites, depthSprite{
+		foot: g.world.Player.Pos.Y + tileSize,
+		draw: func() { g.drawPlayer(screen, camX, camY) },
+	})
+	for _, npc := range g.world.NPCs {
+		if npc.Away {
+			continue
+		}
+		sprites = append(sprites, depthSprite{
+			foot: npc.Pos.Y + tileSize,
+			draw: func() { g.drawNPC(screen, npc, camX, camY) },
+		})
+	}
+	drawSorted(sprites)
+	if g.world.Building != nil {
+		g.drawBuildGhost(screen, camX, camY)
+	}
+
+	// Light the scene for the time of day before the HUD goes on top
+	g.drawLighting(screen, camX, camY)
+	g.drawWeather(screen, camX, camY)
+
 	// Draw status bars and clock at top left as circular pies
 	barRadius := 38
 	barPad := 18
@@ -737,7 +268,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 
 	// Health Pie
-	healthVal := g.health
+	healthVal := g.world.Health
 	if healthVal < 0 {
 		healthVal = 0
 	}
@@ -747,7 +278,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	drawPie(x, y, barRadius, healthVal, color.RGBA{60, 0, 0, 255}, color.RGBA{200, 0, 0, 255}, "Health")
 
 	// Social Pie
-	socialVal := g.social
+	socialVal := g.world.Social
 	if socialVal < 0 {
 		socialVal = 0
 	}
@@ -757,7 +288,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	drawPie(x+barRadius*2+barPad, y, barRadius, socialVal, color.RGBA{0, 0, 60, 255}, color.RGBA{0, 0, 200, 255}, "Social")
 
 	// Hunger Pie
-	hungerVal := g.hunger
+	hungerVal := g.world.Hunger
 	if hungerVal < 0 {
 		hungerVal = 0
 	}
@@ -780,8 +311,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 	}
 	// Draw hour and minute hands
-	gameHour := g.gameMinutes / 60
-	gameMin := g.gameMinutes % 60
+	gameHour := g.world.GameMinutes / 60
+	gameMin := g.world.GameMinutes % 60
 	// Minute hand (longer)
 	minAngle := 2 * math.Pi * (float64(gameMin) / 60.0)
 	minLen := float64(clockRadius) * 0.85
@@ -814,40 +345,38 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	opClock.GeoM.Translate(float64(clockX-clockRadius), float64(clockY-clockRadius))
 	screen.DrawImage(clockImg, opClock)
 
-	// Gradual darken/brighten screen based on time of day
-	// Dawn: 5:00-8:00, Dusk: 18:00-21:00, Night: 21:00-5:00, Day: 8:00-18:00
-	// Alpha: 0 (brightest) to 160 (darkest)
-	hour := float64(gameHour) + float64(gameMin)/60.0
-	var overlayAlpha uint8
-	if hour >= 5 && hour < 8 {
-		// Dawn: fade from dark to bright
-		// 5:00 = 160, 8:00 = 0
-		overlayAlpha = uint8(160 - 160*(hour-5)/3)
-	} else if hour >= 8 && hour < 18 {
-		// Day: brightest
-		overlayAlpha = 0
-	} else if hour >= 18 && hour < 21 {
-		// Dusk: fade from bright to dark
-		// 18:00 = 0, 21:00 = 160
-		overlayAlpha = uint8(160 * (hour - 18) / 3)
-	} else {
-		// Night: darkest
-		overlayAlpha = 160
+	// Date beside the clock
+	date := g.world.Date()
+	dateLines := []string{date.String(), "Week " + strconv.Itoa(date.Week()) + ", Year " + strconv.Itoa(date.Year)}
+	dateImg := ebiten.NewImage(110, 16*len(dateLines)+8)
+	dateImg.Fill(color.RGBA{30, 30, 30, 220})
+	for i, line := range dateLines {
+		ebitenutil.DebugPrintAt(dateImg, line, 6, 4+i*16)
 	}
-	if overlayAlpha > 0 {
-		w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
-		overlay := ebiten.NewImage(w, h)
-		overlay.Fill(color.RGBA{0, 0, 0, overlayAlpha})
-		screen.DrawImage(overlay, nil)
+	opDate := &ebiten.DrawImageOptions{}
+	opDate.GeoM.Translate(float64(clockX+clockRadius+barPad), float64(clockY-dateImg.Bounds().Dy()/2))
+	screen.DrawImage(dateImg, opDate)
+
+	if g.world.Fishing != nil {
+		g.drawFishing(screen, camX, camY)
+	}
+	if g.world.Building != nil {
+		g.drawBuildMenu(screen)
+	}
+
+	// Draw save status message
+	if g.statusTicks > 0 {
+		ebitenutil.DebugPrintAt(screen, g.statusMsg, 10, screen.Bounds().Dy()-20)
 	}
 
 	// Draw chat window if chatting (including fishing/tree dialogues)
-	if g.chatting && g.convNode != nil {
+	if g.world.Chatting && g.world.ConvNode != nil {
 		w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
-		textLines := wrapText(g.convNode.Text, 38)
-		wrappedChoices := make([][]string, len(g.convNode.Choices))
+		textLines := wrapText(g.world.ConvNode.Text, 38)
+		choices := g.world.VisibleChoices()
+		wrappedChoices := make([][]string, len(choices))
 		totalChoiceLines := 0
-		for i, choice := range g.convNode.Choices {
+		for i, choice := range choices {
 			wrapped := wrapText(choice.Text, 38)
 			wrappedChoices[i] = wrapped
 			totalChoiceLines += len(wrapped)
@@ -862,8 +391,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		winImg.Fill(color.RGBA{30, 30, 30, 230})
 		// Show NPC name if present, else show "Action"
 		label := "Action:"
-		if g.chatNPC != nil {
-			label = g.chatNPC.name + ":"
+		if g.world.ChatNPC != nil {
+			label = g.world.ChatNPC.Name + ":"
 		}
 		ebitenutil.DebugPrintAt(winImg, label, 10, 10)
 		for i, line := range textLines {
@@ -874,7 +403,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		for i, lines := range wrappedChoices {
 			for j, line := range lines {
 				prefix := "  "
-				if i == g.chatChoice && j == 0 {
+				if i == g.world.ChatChoice && j == 0 {
 					prefix = "> "
 				}
 				ebitenutil.DebugPrintAt(winImg, prefix+line, 10, choiceY+lineIdx*20)
@@ -888,7 +417,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 
 	// Draw inventory if open
-	if g.inventoryOpen {
+	if g.world.InventoryOpen {
 		w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
 		invW := 400
 		invH := 400
@@ -896,59 +425,45 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		actionH := invH
 		x, y := (w-invW-actionW)/2+actionW, (h-invH)/2
 		actionX, actionY := x-actionW, y
-		invImg := ebiten.NewImage(invW, invH)
-		invImg.Fill(color.RGBA{40, 40, 40, 240})
-		ebitenutil.DebugPrintAt(invImg, "Inventory", 10, 10)
-		cellW := 44
-		cellH := 44
-		for row := 0; row < 8; row++ {
-			for col := 0; col < 8; col++ {
-				slot := g.inventory[row][col]
-				cellX := 10 + col*cellW
-				cellY := 40 + row*cellH
-				// Draw cell border
-				for i := 0; i < cellW; i++ {
-					invImg.Set(cellX+i, cellY, color.RGBA{80, 80, 80, 255})
-					invImg.Set(cellX+i, cellY+cellH-1, color.RGBA{80, 80, 80, 255})
-				}
-				for i := 0; i < cellH; i++ {
-					invImg.Set(cellX, cellY+i, color.RGBA{80, 80, 80, 255})
-					invImg.Set(cellX+cellW-1, cellY+i, color.RGBA{80, 80, 80, 255})
-				}
-				// Draw item name and count, wrapped to cell width, count below name
-				if slot.Item != "" && slot.Count > 0 {
-					nameLines := wrapTextToCell(slot.Item, 7)
-					for i, line := range nameLines {
-						ebitenutil.DebugPrintAt(invImg, line, cellX+4, cellY+6+i*12)
-					}
-					countStr := "x" + strconv.Itoa(slot.Count)
-					ebitenutil.DebugPrintAt(invImg, countStr, cellX+4, cellY+6+len(nameLines)*12)
-				}
-			}
-		}
+		invImg := g.inventoryImage(invW, invH)
 		// Draw actions in a separate window to the left of inventory
 		actionImg := ebiten.NewImage(actionW, actionH)
 		actionImg.Fill(color.RGBA{30, 30, 30, 240})
 		ebitenutil.DebugPrintAt(actionImg, "Inventory Actions", 10, 10)
-		// Wrap action lines to fit action window (max 32 chars per line)
-		actionLines := wrapTextToCell("[C] Cook & Eat Fish (uses 1 Fish + 1 Wood)", 32)
-		for i, line := range actionLines {
-			ebitenutil.DebugPrintAt(actionImg, line, 10, 40+i*16)
-		}
-		eatLines := wrapTextToCell("[E] Eat Cooked Fish (uses 1 Cooked Fish)", 32)
-		for i, line := range eatLines {
-			ebitenutil.DebugPrintAt(actionImg, line, 10, 70+i*16)
+		// List every recipe the player can make right now, wrapped to fit
+		// the action window (max 32 chars per line)
+		lineY := 40
+		craftable := g.world.CraftableRecipes()
+		for i, r := range craftable {
+			prefix := "  "
+			if i == g.world.CraftChoice {
+				prefix = "> "
+			}
+			for _, line := range wrapTextToCell(prefix+r.Name+" ("+g.recipeInputs(r)+")", 32) {
+				ebitenutil.DebugPrintAt(actionImg, line, 10, lineY)
+				lineY += 16
+			}
 		}
-		closeLines := wrapTextToCell("[Space] Close", 32)
-		for i, line := range closeLines {
-			ebitenutil.DebugPrintAt(actionImg, line, 10, 110+i*16)
+		if len(craftable) == 0 && g.world.Crafting == nil {
+			ebitenutil.DebugPrintAt(actionImg, "Nothing to craft.", 10, lineY)
+			lineY += 16
 		}
-		// Optionally, show a message if not enough resources
-		if !g.hasItem("Fish", 1) || !g.hasItem("Wood", 1) {
-			warnLines := wrapTextToCell("Need 1 Fish and 1 Wood to cook!", 32)
-			for i, line := range warnLines {
-				ebitenutil.DebugPrintAt(actionImg, line, 10, 150+i*16)
+		if job := g.world.Crafting; job != nil {
+			lineY += 8
+			status := strconv.Itoa(job.MinutesLeft) + " min"
+			if job.Blocked != "" {
+				status = "waiting"
 			}
+			ebitenutil.DebugPrintAt(actionImg, "Making "+job.Recipe.Name+": "+status, 10, lineY)
+		}
+		helpLines := []string{
+			"[Up/Down] Select recipe",
+			"[Enter] Craft",
+			"[E] Eat",
+			"[Space] Close",
+		}
+		for i, line := range helpLines {
+			ebitenutil.DebugPrintAt(actionImg, line, 10, actionH-16*len(helpLines)-10+i*16)
 		}
 		opAction := &ebiten.DrawImageOptions{}
 		opAction.GeoM.Translate(float64(actionX), float64(actionY))
@@ -959,309 +474,324 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		return // Don't draw rest of game when inventory is open
 	}
 
+	// Draw trade window next to the inventory while trading
+	if g.world.Trading != nil {
+		w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+		invW := 400
+		invH := 400
+		tradeW := 260
+		x, y := (w-invW-tradeW)/2+tradeW, (h-invH)/2
+		opTrade := &ebiten.DrawImageOptions{}
+		opTrade.GeoM.Translate(float64(x-tradeW), float64(y))
+		screen.DrawImage(g.tradeImage(tradeW, invH), opTrade)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(x), float64(y))
+		screen.DrawImage(g.inventoryImage(invW, invH), op)
+		return
+	}
+
 	// Draw game over overlay
-	if g.gameOver {
+	if g.world.GameOver {
 		w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
 		overlay := ebiten.NewImage(w, h)
 		overlay.Fill(color.RGBA{0, 0, 0, 180})
 		screen.DrawImage(overlay, nil)
-		ebitenutil.DebugPrintAt(screen, "GAME OVER\nPress Space/Enter/Mouse to Restart", w/2-80, h/2-10)
+		msg := "GAME OVER"
+		if d := g.world.Death; d != nil {
+			msg += "\n\nYou died of " + d.Cause + "." +
+				"\nDays survived: " + strconv.Itoa(d.Stats.DaysSurvived()) +
+				"\nItems gathered: " + strconv.Itoa(d.Stats.ItemsGathered)
+		}
+		msg += "\n\nPress Space/Enter/Mouse to Restart"
+		ebitenutil.DebugPrintAt(screen, msg, w/2-100, h/2-50)
 	}
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	// Return viewport size (half the scaled map size)
-	w := g.mapData.Width * tileSize * scale / 2
-	h := g.mapData.Height * tileSize * scale / 2
-	if w > g.mapData.Width*tileSize*scale {
-		w = g.mapData.Width * tileSize * scale
+// drawFishing shows the state of a fishing attempt above the player: a
+// bobber while waiting, a "!" when something bites, the timing bar while
+// reeling and the result at the end.
+func (g *Game) drawFishing(screen *ebiten.Image, camX, camY int) {
+	f := g.world.Fishing
+	x := g.world.Player.Pos.X*scale - camX + tileSize*scale/2
+	y := g.world.Player.Pos.Y*scale - camY - 24
+	switch f.Phase {
+	case sim.FishWaiting:
+		dots := strings.Repeat(".", 1+g.world.Tick/20%3)
+		ebitenutil.DebugPrintAt(screen, dots, x-9, y)
+	case sim.FishBite:
+		ebitenutil.DebugPrintAt(screen, "!", x-3, y)
+	case sim.FishReeling:
+		const barW, barH = 120, 10
+		bar := ebiten.NewImage(barW, barH)
+		bar.Fill(color.RGBA{30, 30, 30, 230})
+		zone := bar.SubImage(image.Rect(int(f.ZoneLo*barW), 0, int(f.ZoneHi*barW), barH)).(*ebiten.Image)
+		zone.Fill(color.RGBA{60, 180, 80, 255})
+		marker := int(f.Marker * (barW - 2))
+		bar.SubImage(image.Rect(marker, 0, marker+2, barH)).(*ebiten.Image).Fill(color.White)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(x-barW/2), float64(y))
+		screen.DrawImage(bar, op)
+	case sim.FishDone:
+		ebitenutil.DebugPrintAt(screen, f.Message, x-len(f.Message)*3, y)
 	}
-	if h > g.mapData.Height*tileSize*scale {
-		h = g.mapData.Height * tileSize * scale
+}
+
+// drawBuildGhost previews the selected structure on the tile in front of
+// the player, tinted red where it can't be placed or paid for.
+func (g *Game) drawBuildGhost(screen *ebiten.Image, camX, camY int) {
+	b := g.world.SelectedBuildable()
+	tile, err := g.world.Map.TileGIDToTile(b.Tile)
+	if err != nil || tile.Tileset == nil {
+		return
+	}
+	img := g.atlas.frame(tile.Tileset, tile.ID, g.animMillis())
+	if img == nil {
+		return
+	}
+	t, free := g.world.BuildTarget()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM = tileGeoM(tile, img, g.world.Map.TileHeight)
+	op.GeoM.Translate(float64(t.X*tileSize), float64(t.Y*tileSize))
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(float64(-camX), float64(-camY))
+	if free && g.world.CanAfford(b) {
+		op.ColorScale.ScaleAlpha(0.6)
+	} else {
+		op.ColorScale.Scale(1, 0.3, 0.3, 0.6)
 	}
-	return w, h
+	screen.DrawImage(img, op)
 }
 
-// Add a restart method to reset the game state
-func (g *Game) restart() {
-	// Center player
-	startX := (g.mapData.Width*tileSize - tileSize) / 2
-	startY := (g.mapData.Height*tileSize - tileSize) / 2
-	g.playerPos = image.Point{X: startX, Y: startY}
-	g.playerAnim = 0
-	g.playerAnimTick = 0
-	g.moving = false
-	g.gameOver = false
+// drawBuildMenu lists the buildables and their cost at the right edge of
+// the screen, with the selected one highlighted.
+func (g *Game) drawBuildMenu(screen *ebiten.Image) {
+	const menuW = 260
+	lines := []string{"Build", ""}
+	for _, b := range g.world.Buildables {
+		prefix := "  "
+		if b == g.world.SelectedBuildable() {
+			prefix = "> "
+		}
+		cost := make([]string, len(b.Cost))
+		for j, c := range b.Cost {
+			cost[j] = strconv.Itoa(c.Count) + " " + g.world.ItemName(c.Item)
+		}
+		lines = append(lines, wrapTextToCell(prefix+b.Name+" ("+strings.Join(cost, ", ")+")", 40)...)
+	}
+	lines = append(lines, "", "[Tab] Next  [Space] Place", "[B/Esc] Close")
+	menu := ebiten.NewImage(menuW, 16*len(lines)+20)
+	menu.Fill(color.RGBA{30, 30, 30, 220})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(menu, line, 10, 10+i*16)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(screen.Bounds().Dx()-menuW-20), 20)
+	screen.DrawImage(menu, op)
 }
 
-func (g *Game) spawnNPCs() {
-	g.npcs = nil
-	// Place NPCs at fixed locations for demo
-	positions := []struct {
-		name   string
-		sprite string
-		x, y   int
-	}{
-		{"Kid", "assets/Kid01_idle.png", 2 * tileSize, 2 * tileSize},
-		{"Merchant", "assets/Merchant_idle.png", 5 * tileSize, 5 * tileSize},
-		{"Alchemist", "assets/Alchemist_idle.png", 8 * tileSize, 8 * tileSize},
-	}
-	for _, p := range positions {
-		img, _, err := ebitenutil.NewImageFromFile(p.sprite)
-		if err != nil {
-			log.Printf("failed to load NPC sprite %s: %v", p.sprite, err)
-			continue
+// drawPlayer draws the player using the idle/walk sprite sheet if loaded.
+func (g *Game) drawPlayer(screen *ebiten.Image, camX, camY int) {
+	spriteW, spriteH := 32, 48 // Each frame is 32x48 pixels for 128x192 sheets (4x4)
+	var spriteSheet *ebiten.Image
+	if g.world.Player.Moving && g.walkSprite != nil {
+		spriteSheet = g.walkSprite
+	} else if g.idleSprite != nil {
+		spriteSheet = g.idleSprite
+	}
+	if spriteSheet != nil {
+		sx := g.world.Player.Anim * spriteW
+		// Map playerDir to correct row in sprite sheet:
+		// 0=down (row 0), 1=right (row 2), 2=left (row 1), 3=up (row 3)
+		var animRow int
+		switch g.world.Player.Dir {
+		case 0: // down
+			animRow = 0
+		case 1: // right
+			animRow = 1
+		case 2: // left
+			animRow = 2
+		case 3: // up
+			animRow = 3
 		}
-		g.npcs = append(g.npcs, &NPC{
-			pos:    image.Point{X: p.x, Y: p.y},
-			dir:    0,
-			name:   p.name,
-			sprite: img,
-		})
+		sy := animRow * spriteH
+		src := image.Rect(sx, sy, sx+spriteW, sy+spriteH)
+
+		playerSize := tileSize
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(
+			float64(playerSize)/float64(spriteW)*scale,
+			float64(playerSize)/float64(spriteH)*scale,
+		)
+		op.GeoM.Translate(float64(g.world.Player.Pos.X*scale-camX), float64(g.world.Player.Pos.Y*scale-camY))
+		screen.DrawImage(spriteSheet.SubImage(src).(*ebiten.Image), op)
+	} else {
+		// fallback: red square
+		playerSize := tileSize / 4
+		playerImg := ebiten.NewImage(playerSize, playerSize)
+		playerImg.Fill(color.RGBA{255, 0, 0, 255})
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(float64(g.world.Player.Pos.X*scale-camX), float64(g.world.Player.Pos.Y*scale-camY))
+		screen.DrawImage(playerImg, op)
 	}
 }
 
-func (g *Game) initConversations() {
-	g.conversations = map[string]*ConversationNode{}
+// drawNPC draws an NPC with animation (same logic as enemies).
+func (g *Game) drawNPC(screen *ebiten.Image, npc *sim.NPC, camX, camY int) {
+	spriteW, spriteH := 32, 48
+	if sprite := g.spriteImage(npc.Sprite); sprite != nil {
+		sx := npc.Anim * spriteW
+		var animRow int
+		switch npc.Dir {
+		case 0: // down
+			animRow = 0
+		case 1: // right
+			animRow = 2 // swap right to left row
+		case 2: // left
+			animRow = 1 // swap left to right row
+		case 3: // up
+			animRow = 3
+		}
+		sy := animRow * spriteH
+		src := image.Rect(sx, sy, sx+spriteW, sy+spriteH)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(tileSize)/float64(spriteW)*scale, float64(tileSize)/float64(spriteH)*scale)
+		op.GeoM.Translate(float64(npc.Pos.X*scale-camX), float64(npc.Pos.Y*scale-camY))
+		screen.DrawImage(sprite.SubImage(src).(*ebiten.Image), op)
+	}
+}
 
-	// Kid conversation
-	var kidRoot *ConversationNode
-	kidEnd := &ConversationNode{
-		Text: "See you later!",
-		Choices: []ConversationChoice{
-			{Text: "Goodbye", Next: nil},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // placeholder, set below
-		},
-	}
-	kidJoke := &ConversationNode{
-		Text: "Why did the chicken cross the playground? To get to the other slide!",
-		Choices: []ConversationChoice{
-			{Text: "Haha! Got any more?", Next: kidEnd},
-			{Text: "That's silly.", Next: kidEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	kidFav := &ConversationNode{
-		Text: "I love playing tag! What's your favorite game?",
-		Choices: []ConversationChoice{
-			{Text: "Hide and seek!", Next: kidEnd},
-			{Text: "Chess.", Next: kidEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	kidHowAreYou := &ConversationNode{
-		Text: "I'm great! It's a fun day.",
-		Choices: []ConversationChoice{
-			{Text: "Glad to hear!", Next: kidEnd},
-			{Text: "Tell me a joke!", Next: kidJoke},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	kidRoot = &ConversationNode{
-		Text: "Hi! I'm the Kid. What do you want to talk about?",
-		Choices: []ConversationChoice{
-			{Text: "Tell me a joke!", Next: kidJoke},
-			{Text: "What's your favorite game?", Next: kidFav},
-			{Text: "How are you?", Next: kidHowAreYou},
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	// Set "How interesting..." choices to point to root
-	kidEnd.Choices[1].Next = kidRoot
-	kidJoke.Choices[2].Next = kidRoot
-	kidFav.Choices[2].Next = kidRoot
-	kidHowAreYou.Choices[2].Next = kidRoot
-	g.conversations["Kid"] = kidRoot
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	// Return viewport size (half the scaled start map size)
+	return g.viewW, g.viewH
+}
 
-	// Merchant conversation
-	var merchantRoot *ConversationNode
-	merchantEnd := &ConversationNode{
-		Text: "Safe travels, friend!",
-		Choices: []ConversationChoice{
-			{Text: "Goodbye", Next: nil},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-		},
-	}
-	merchantTrade := &ConversationNode{
-		Text: "Sorry, my shop is closed today.",
-		Choices: []ConversationChoice{
-			{Text: "Oh, that's too bad.", Next: merchantEnd},
-			{Text: "What do you sell?", Next: &ConversationNode{
-				Text: "Mostly potions and trinkets.",
-				Choices: []ConversationChoice{
-					{Text: "Sounds interesting!", Next: merchantEnd},
-					{Text: "Can I see your wares?", Next: nil},                                        // Prevent infinite loop
-					{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-					{Text: "Goodbye", Next: nil},
-				},
-			}},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	merchantAnyNews := &ConversationNode{
-		Text: "The harvest festival is coming soon.",
-		Choices: []ConversationChoice{
-			{Text: "Will there be games?", Next: merchantEnd},
-			{Text: "Will you have a booth?", Next: merchantEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	merchantWhereFrom := &ConversationNode{
-		Text: "From the city to the east.",
-		Choices: []ConversationChoice{
-			{Text: "Do you miss it?", Next: merchantEnd},
-			{Text: "Why did you move?", Next: merchantEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	merchantRoot = &ConversationNode{
-		Text: "Welcome! How can I help you?",
-		Choices: []ConversationChoice{
-			{Text: "Can I buy something?", Next: merchantTrade},
-			{Text: "Any news?", Next: merchantAnyNews},
-			{Text: "Where are you from?", Next: merchantWhereFrom},
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	// Set "How interesting..." choices to point to root
-	merchantEnd.Choices[1].Next = merchantRoot
-	merchantTrade.Choices[2].Next = merchantRoot
-	merchantTrade.Choices[3].Next = merchantRoot
-	merchantAnyNews.Choices[2].Next = merchantRoot
-	merchantWhereFrom.Choices[2].Next = merchantRoot
-	if merchantTrade.Choices[1].Next != nil {
-		merchantTrade.Choices[1].Next.Choices[2].Next = merchantRoot
-	}
-	g.conversations["Merchant"] = merchantRoot
+// spriteImage loads and caches a character sprite sheet by path.
+func (g *Game) spriteImage(path string) *ebiten.Image {
+	if img, ok := g.sprites[path]; ok {
+		return img
+	}
+	img, _, err := ebitenutil.NewImageFromFile(path)
+	if err != nil {
+		log.Printf("failed to load NPC sprite %s: %v", path, err)
+		img = nil
+	}
+	g.sprites[path] = img
+	return img
+}
 
-	// Alchemist conversation
-	var alchemistRoot *ConversationNode
-	alchemistEnd := &ConversationNode{
-		Text: "Farewell, may your path be clear.",
-		Choices: []ConversationChoice{
-			{Text: "Goodbye", Next: nil},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-		},
-	}
-	alchemistTeach := &ConversationNode{
-		Text: "Alchemy is a lifelong pursuit. Start with herbs.",
-		Choices: []ConversationChoice{
-			{Text: "Which herbs?", Next: alchemistEnd},
-			{Text: "Is it dangerous?", Next: alchemistEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	alchemistWork := &ConversationNode{
-		Text: "A potion for better memory.",
-		Choices: []ConversationChoice{
-			{Text: "Can I try it?", Next: alchemistEnd},
-			{Text: "Does it work?", Next: alchemistEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	alchemistMagic := &ConversationNode{
-		Text: "Of course. Magic is everywhere.",
-		Choices: []ConversationChoice{
-			{Text: "Show me!", Next: alchemistEnd},
-			{Text: "I don't believe you.", Next: alchemistEnd},
-			{Text: "How interesting, I wanted to ask you about something else...", Next: nil}, // set below
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	alchemistRoot = &ConversationNode{
-		Text: "Greetings, traveler. What knowledge do you seek?",
-		Choices: []ConversationChoice{
-			{Text: "Can you teach me alchemy?", Next: alchemistTeach},
-			{Text: "What are you working on?", Next: alchemistWork},
-			{Text: "Do you believe in magic?", Next: alchemistMagic},
-			{Text: "Goodbye", Next: nil},
-		},
-	}
-	// Set "How interesting..." choices to point to root
-	alchemistEnd.Choices[1].Next = alchemistRoot
-	alchemistTeach.Choices[2].Next = alchemistRoot
-	alchemistWork.Choices[2].Next = alchemistRoot
-	alchemistMagic.Choices[2].Next = alchemistRoot
-	g.conversations["Alchemist"] = alchemistRoot
+// animMillis is the game time that drives tile animations. It follows the
+// simulation tick so animations pause with the game.
+func (g *Game) animMillis() int {
+	return g.world.Tick * 1000 / sim.TicksPerSecond
 }
 
-func (g *Game) addToInventory(item string, count int) {
-	maxPerCell := 5
-	if item == "Wood" {
-		maxPerCell = 5
-	}
-	// Try to stack first, up to maxPerCell
-	for y := 0; y < 8; y++ {
-		for x := 0; x < 8; x++ {
-			slot := &g.inventory[y][x]
-			if slot.Item == item && slot.Count > 0 && slot.Count < maxPerCell {
-				add := count
-				if slot.Count+add > maxPerCell {
-					add = maxPerCell - slot.Count
-				}
-				slot.Count += add
-				count -= add
-				if count <= 0 {
-					return
-				}
-			}
+// itemIcon returns an item's picture from the roguelike tileset, or nil.
+func (g *Game) itemIcon(item string) *ebiten.Image {
+	def := g.world.Items[item]
+	if def == nil || def.Icon < 0 {
+		return nil
+	}
+	for _, ts := range g.world.Map.Tilesets {
+		if strings.HasPrefix(ts.Name, "roguelikeSheet") {
+			return g.atlas.frame(ts, uint32(def.Icon), g.animMillis())
 		}
 	}
-	// Find first empty slot(s)
-	for y := 0; y < 8 && count > 0; y++ {
-		for x := 0; x < 8 && count > 0; x++ {
-			slot := &g.inventory[y][x]
+	return nil
+}
+
+// inventoryImage draws the 8x8 inventory grid.
+func (g *Game) inventoryImage(invW, invH int) *ebiten.Image {
+	invImg := ebiten.NewImage(invW, invH)
+	invImg.Fill(color.RGBA{40, 40, 40, 240})
+	ebitenutil.DebugPrintAt(invImg, "Inventory", 10, 10)
+	cellW := 44
+	cellH := 44
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			slot := g.world.Inventory[row][col]
+			cellX := 10 + col*cellW
+			cellY := 40 + row*cellH
+			// Draw cell border
+			for i := 0; i < cellW; i++ {
+				invImg.Set(cellX+i, cellY, color.RGBA{80, 80, 80, 255})
+				invImg.Set(cellX+i, cellY+cellH-1, color.RGBA{80, 80, 80, 255})
+			}
+			for i := 0; i < cellH; i++ {
+				invImg.Set(cellX, cellY+i, color.RGBA{80, 80, 80, 255})
+				invImg.Set(cellX+cellW-1, cellY+i, color.RGBA{80, 80, 80, 255})
+			}
 			if slot.Item == "" || slot.Count == 0 {
-				add := count
-				if add > maxPerCell {
-					add = maxPerCell
+				continue
+			}
+			// Draw the item icon with the count in the corner, falling back
+			// to the name for items without one
+			if icon := g.itemIcon(slot.Item); icon != nil {
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Scale(2, 2)
+				op.GeoM.Translate(float64(cellX+6), float64(cellY+4))
+				invImg.DrawImage(icon, op)
+			} else {
+				for i, line := range wrapTextToCell(g.world.ItemName(slot.Item), 7) {
+					ebitenutil.DebugPrintAt(invImg, line, cellX+4, cellY+6+i*12)
 				}
-				slot.Item = item
-				slot.Count = add
-				count -= add
 			}
+			ebitenutil.DebugPrintAt(invImg, strconv.Itoa(slot.Count), cellX+cellW-6-6*len(strconv.Itoa(slot.Count)), cellY+cellH-16)
 		}
 	}
+	return invImg
 }
 
-func (g *Game) hasItem(item string, count int) bool {
-	total := 0
-	for y := 0; y < 8; y++ {
-		for x := 0; x < 8; x++ {
-			slot := &g.inventory[y][x]
-			if slot.Item == item {
-				total += slot.Count
-				if total >= count {
-					return true
-				}
-			}
+// tradeImage draws the shop's price list with the current selection.
+func (g *Game) tradeImage(tradeW, tradeH int) *ebiten.Image {
+	shop := g.world.Trading
+	img := ebiten.NewImage(tradeW, tradeH)
+	img.Fill(color.RGBA{30, 30, 30, 240})
+	title := shop.Def.NPC + " - Buying"
+	if g.world.TradeSelling {
+		title = shop.Def.NPC + " - Selling"
+	}
+	ebitenutil.DebugPrintAt(img, title, 10, 10)
+	ebitenutil.DebugPrintAt(img, "Coins: "+strconv.Itoa(g.world.CountItem(sim.Currency)), 10, 26)
+	// Lines scroll to keep the selection in view above the message
+	lines := g.world.TradeLines()
+	rows := (tradeH - 120 - 50) / 20
+	first := max(g.world.TradeChoice-rows+1, 0)
+	if first > 0 {
+		ebitenutil.DebugPrintAt(img, "^", tradeW-20, 50)
+	}
+	if first+rows < len(lines) {
+		ebitenutil.DebugPrintAt(img, "v", tradeW-20, 50+(rows-1)*20)
+	}
+	for i, it := range lines[first:min(first+rows, len(lines))] {
+		i += first
+		prefix := "  "
+		if i == g.world.TradeChoice {
+			prefix = "> "
+		}
+		var line string
+		if g.world.TradeSelling {
+			line = g.world.ItemName(it.Item) + " +" + strconv.Itoa(it.Sell) + "c"
+		} else {
+			line = g.world.ItemName(it.Item) + " " + strconv.Itoa(it.Buy) + "c (" + strconv.Itoa(shop.Stock[it.Item]) + " left)"
 		}
+		ebitenutil.DebugPrintAt(img, prefix+line, 10, 50+(i-first)*20)
 	}
-	return false
+	y := tradeH - 120
+	for i, line := range wrapTextToCell(g.world.TradeMsg, 32) {
+		ebitenutil.DebugPrintAt(img, line, 10, y+i*16)
+	}
+	ebitenutil.DebugPrintAt(img, "[Up/Down] Select", 10, tradeH-70)
+	ebitenutil.DebugPrintAt(img, "[Left/Right] Buy/Sell", 10, tradeH-54)
+	ebitenutil.DebugPrintAt(img, "[Space] Trade  [Esc] Leave", 10, tradeH-38)
+	return img
 }
 
-func (g *Game) removeItem(item string, count int) {
-	for y := 0; y < 8 && count > 0; y++ {
-		for x := 0; x < 8 && count > 0; x++ {
-			slot := &g.inventory[y][x]
-			if slot.Item == item && slot.Count > 0 {
-				if slot.Count > count {
-					slot.Count -= count
-					return
-				} else {
-					count -= slot.Count
-					slot.Count = 0
-					slot.Item = ""
-				}
-			}
-		}
+// recipeInputs describes what a recipe uses, e.g. "1 Fish + 1 Wood".
+func (g *Game) recipeInputs(r *sim.Recipe) string {
+	parts := make([]string, len(r.Inputs))
+	for i, in := range r.Inputs {
+		parts[i] = strconv.Itoa(in.Count) + " " + g.world.ItemName(in.Item)
 	}
+	return strings.Join(parts, " + ")
 }
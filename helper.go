@@ -1,7 +1,6 @@
 package main
 
 import (
-	"image"
 	"image/color"
 	"math"
 	"strings"
@@ -85,23 +84,6 @@ func wrapTextToCell(text string, maxChars int) []string {
 	return lines
 }
 
-func isFacingNPC(g *Game, npc *NPC) bool {
-	// Player must be within a 2x2 tile area around the NPC (more generous)
-	playerRect := image.Rect(g.playerPos.X, g.playerPos.Y, g.playerPos.X+tileSize, g.playerPos.Y+tileSize) // 4x greater
-	npcRect := image.Rect(npc.pos.X-tileSize/2, npc.pos.Y-tileSize/2, npc.pos.X+tileSize*3/2, npc.pos.Y+tileSize*3/2)
-	return playerRect.Overlaps(npcRect)
-}
-
-func sign(x int) int {
-	if x < 0 {
-		return -1
-	}
-	if x > 0 {
-		return 1
-	}
-	return 0
-}
-
 func wrapText(text string, maxWidth int) []string {
 	// Simple word wrap: splits text into lines not exceeding maxWidth (in runes)
 	words := strings.Fields(text)
@@ -123,10 +105,3 @@ func wrapText(text string, maxWidth int) []string {
 	}
 	return lines
 }
-
-func abs(x int) int {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
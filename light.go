@@ -0,0 +1,139 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jmainguy/survival-game/sim"
+)
+
+// lightMaskSize is the side of the gradient image, scaled to each light.
+const lightMaskSize = 128
+
+// twilight is how long dawn and dusk take either side of sunrise and sunset.
+const twilight = 90
+
+// Colours of daylight: a cool blue night, a pink dawn, white day and an
+// orange dusk.
+var (
+	nightColor = color.RGBA{70, 80, 130, 255}
+	dawnColor  = color.RGBA{200, 150, 160, 255}
+	dayColor   = color.RGBA{255, 255, 255, 255}
+	duskColor  = color.RGBA{235, 150, 100, 255}
+)
+
+type ambientKey struct {
+	minute int
+	color  color.RGBA
+}
+
+// ambientKeys is the colour of daylight through a day with the given sunrise
+// and sunset, in game minutes. Colours in between are blended.
+func ambientKeys(rise, set int) []ambientKey {
+	return []ambientKey{
+		{0, nightColor},
+		{rise - twilight, nightColor},
+		{rise, dawnColor},
+		{rise + twilight, dayColor},
+		{set - twilight, dayColor},
+		{set, duskColor},
+		{set + twilight, nightColor},
+		{sim.MinutesPerDay, nightColor},
+	}
+}
+
+// blendMultiply multiplies the scene by the light map, keeping its alpha.
+var blendMultiply = ebiten.Blend{
+	BlendFactorSourceRGB:        ebiten.BlendFactorDestinationColor,
+	BlendFactorSourceAlpha:      ebiten.BlendFactorZero,
+	BlendFactorDestinationRGB:   ebiten.BlendFactorZero,
+	BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+	BlendOperationRGB:           ebiten.BlendOperationAdd,
+	BlendOperationAlpha:         ebiten.BlendOperationAdd,
+}
+
+// ambientLight is the daylight colour at a time of day.
+func ambientLight(minutes, rise, set int) color.RGBA {
+	keys := ambientKeys(rise, set)
+	for i := 1; i < len(keys); i++ {
+		a, b := keys[i-1], keys[i]
+		if minutes > b.minute {
+			continue
+		}
+		t := float64(minutes-a.minute) / float64(b.minute-a.minute)
+		mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+		return color.RGBA{mix(a.color.R, b.color.R), mix(a.color.G, b.color.G), mix(a.color.B, b.color.B), 255}
+	}
+	return keys[len(keys)-1].color
+}
+
+// drawLighting tints the scene with the daylight colour and adds the lights
+// of the map on top. The light map starts as the ambient colour, each light
+// is added to it as a coloured radial gradient, and the result multiplies
+// the scene, so a light can at most bring a spot back to full daylight.
+func (g *Game) drawLighting(screen *ebiten.Image, camX, camY int) {
+	rise, set := g.world.Daylight()
+	ambient := weatherDim(ambientLight(g.world.GameMinutes, rise, set), g.world)
+	if ambient == (color.RGBA{255, 255, 255, 255}) {
+		return
+	}
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	if g.lightMap == nil || g.lightMap.Bounds().Dx() != w || g.lightMap.Bounds().Dy() != h {
+		g.lightMap = ebiten.NewImage(w, h)
+	}
+	g.lightMap.Fill(ambient)
+	mask := g.lightMaskImage()
+	ms := g.animMillis()
+	for _, l := range g.world.Lights() {
+		f := flicker(l, ms)
+		r := l.Radius * f * scale
+		x, y := float64(l.X*scale-camX), float64(l.Y*scale-camY)
+		if x+r < 0 || y+r < 0 || x-r > float64(w) || y-r > float64(h) {
+			continue
+		}
+		op := &ebiten.DrawImageOptions{Blend: ebiten.BlendLighter}
+		op.GeoM.Translate(-lightMaskSize/2, -lightMaskSize/2)
+		op.GeoM.Scale(2*r/lightMaskSize, 2*r/lightMaskSize)
+		op.GeoM.Translate(x, y)
+		op.ColorScale.ScaleWithColor(l.Color)
+		op.ColorScale.Scale(float32(f), float32(f), float32(f), float32(f))
+		g.lightMap.DrawImage(mask, op)
+	}
+	screen.DrawImage(g.lightMap, &ebiten.DrawImageOptions{Blend: blendMultiply})
+}
+
+// flicker is how bright and large a light is right now relative to its
+// size, wobbling by up to its Flicker. Each light gets its own phase from
+// where it is so fires don't pulse in step.
+func flicker(l sim.Light, ms int) float64 {
+	if l.Flicker == 0 {
+		return 1
+	}
+	t := float64(ms) / 1000
+	p := float64(l.X*7 + l.Y*13)
+	n := 0.5*math.Sin(t*9+p) + 0.3*math.Sin(t*23+p*1.7) + 0.2*math.Sin(t*37+p*2.3)
+	return 1 + 0.5*l.Flicker*n
+}
+
+// lightMaskImage returns a white disc fading out towards its edge.
+func (g *Game) lightMaskImage() *ebiten.Image {
+	if g.lightMask != nil {
+		return g.lightMask
+	}
+	pix := make([]byte, 4*lightMaskSize*lightMaskSize)
+	r := lightMaskSize / 2.0
+	for y := range lightMaskSize {
+		for x := range lightMaskSize {
+			d := math.Hypot(float64(x)+0.5-r, float64(y)+0.5-r) / r
+			a := math.Max(0, 1-d)
+			a = a * a * (3 - 2*a) // smooth falloff
+			v := byte(255 * a)    // premultiplied white
+			i := 4 * (y*lightMaskSize + x)
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = v, v, v, v
+		}
+	}
+	g.lightMask = ebiten.NewImage(lightMaskSize, lightMaskSize)
+	g.lightMask.WritePixels(pix)
+	return g.lightMask
+}
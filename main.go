@@ -1,45 +1,45 @@
 package main
 
 import (
-	"image"
+	"flag"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jmainguy/survival-game/sim"
 	"github.com/lafriks/go-tiled"
 )
 
 const (
-	tileSize = 15 // Adjusted to match actual tile size
-	scale    = 2  // Zoom in 2x
+	tileSize = sim.TileSize
+	scale    = 2 // Zoom in 2x
+
+	sampleRate = 44100
+	startMap   = "jons_first_map"
 )
 
 func main() {
-	// Load map
-	mapData, err := tiled.LoadFile("assets/jons_first_map.tmx")
+	saveDir := flag.String("saves", "saves", "directory holding save slots")
+	slot := flag.String("slot", "slot1", "save slot written by quick save (F5)")
+	load := flag.String("load", "", "load this save slot and skip the title menu")
+	autosave := flag.Int("autosave", 60, "in-game minutes between autosaves, 0 disables")
+	flag.Parse()
+
+	// Load the start map; the others load when the player first walks in
+	loadMap := func(name string) (*tiled.Map, error) {
+		return tiled.LoadFile("assets/" + name + ".tmx")
+	}
+	mapData, err := loadMap(startMap)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	tilesetImgs := make(map[string]*ebiten.Image)
-	for _, ts := range mapData.Tilesets {
-		imgPath := ts.Image.Source
-		if imgPath != "" && imgPath[0] != '/' {
-			imgPath = "assets/" + imgPath
-		}
-		tileImg, _, err := ebitenutil.NewImageFromFile(imgPath)
-		if err != nil {
-			log.Printf("failed to load tileset image %s: %v", imgPath, err)
-			continue
-		}
-		tilesetImgs[imgPath] = tileImg
-	}
-
 	// Load character idle and walk sprite sheets for Farmer
 	idleSprite, _, err := ebitenutil.NewImageFromFile("assets/Farmer_idle.png")
 	if err != nil {
@@ -64,37 +64,84 @@ func main() {
 	}
 	rand.Shuffle(len(musicFiles), func(i, j int) { musicFiles[i], musicFiles[j] = musicFiles[j], musicFiles[i] })
 
-	const sampleRate = 44100
 	audioContext := audio.NewContext(sampleRate)
 
-	// Calculate player starting position: center of map
-	startX := (mapData.Width*tileSize - tileSize) / 2
-	startY := (mapData.Height*tileSize - tileSize) / 2
-
+	// The player starts at the start map's player_spawn object at 08:00
 	game := &Game{
-		mapData:      mapData,
-		playerPos:    image.Point{X: startX, Y: startY},
-		tilesetImgs:  tilesetImgs,
+		world:        sim.NewWorld(startMap, mapData, time.Now().UnixNano()),
+		atlas:        newTileAtlas(),
+		chunks:       newChunkCache(),
+		sprites:      make(map[string]*ebiten.Image),
 		idleSprite:   idleSprite,
 		walkSprite:   walkSprite,
 		audioContext: audioContext,
 		musicFiles:   musicFiles,
 		musicPlayed:  []string{},
-		health:       1.0,
-		social:       1.0,
-		hunger:       1.0,
-		gameMinutes:  8 * 60, // Start at 08:00
+		saveDir:      *saveDir,
+		slot:         *slot,
+	}
+	game.world.AutosaveEvery = *autosave
+	game.world.LoadMap = loadMap
+	game.world.OnTileChange = game.chunks.invalidate
+	game.world.NPCDefs, err = sim.LoadNPCs("assets/npcs.json")
+	if err != nil {
+		log.Fatalf("failed to load NPCs: %v", err)
+	}
+	game.world.Items, err = sim.LoadItems("assets/items.json")
+	if err != nil {
+		log.Fatalf("failed to load items: %v", err)
+	}
+	game.world.Conversations, err = sim.LoadConversations("assets/dialogue")
+	if err != nil {
+		log.Fatalf("failed to load dialogue: %v", err)
+	}
+	game.world.Shops, err = sim.LoadShops("assets/shops.json")
+	if err != nil {
+		log.Fatalf("failed to load shops: %v", err)
+	}
+	game.world.Recipes, err = sim.LoadRecipes("assets/recipes.json")
+	if err != nil {
+		log.Fatalf("failed to load recipes: %v", err)
+	}
+	game.world.Resources, err = sim.LoadResources("assets/resources.json")
+	if err != nil {
+		log.Fatalf("failed to load resources: %v", err)
+	}
+	game.world.LootTables, err = sim.LoadLootTables("assets/loot.json")
+	if err != nil {
+		log.Fatalf("failed to load loot tables: %v", err)
+	}
+	game.world.Farm, err = sim.LoadFarm("assets/farm.json")
+	if err != nil {
+		log.Fatalf("failed to load farm: %v", err)
+	}
+	game.world.Buildables, err = sim.LoadBuildables("assets/buildables.json")
+	if err != nil {
+		log.Fatalf("failed to load buildables: %v", err)
+	}
+	if err := game.world.Validate(); err != nil {
+		log.Fatalf("invalid game data: %v", err)
+	}
+	// Validate loaded every reachable map, so NPCs placed on any of them spawn
+	game.world.Reset()
+	if *load != "" {
+		if err := game.loadSlot(*load); err != nil {
+			log.Fatalf("failed to load save slot %s: %v", *load, err)
+		}
+	} else {
+		game.title = newTitleMenu(*saveDir)
 	}
-	game.spawnNPCs()
 	// Set window size to half the scaled map size
-	winW := game.mapData.Width * tileSize * scale / 2
-	winH := game.mapData.Height * tileSize * scale / 2
-	if winW > game.mapData.Width*tileSize*scale {
-		winW = game.mapData.Width * tileSize * scale
+	winW := mapData.Width * tileSize * scale / 2
+	winH := mapData.Height * tileSize * scale / 2
+	if winW > mapData.Width*tileSize*scale {
+		winW = mapData.Width * tileSize * scale
 	}
-	if winH > game.mapData.Height*tileSize*scale {
-		winH = game.mapData.Height * tileSize * scale
+	if winH > mapData.Height*tileSize*scale {
+		winH = mapData.Height * tileSize * scale
 	}
+	// Keep the screen this size on every map; smaller maps are centred
+	game.viewW, game.viewH = winW, winH
 	ebiten.SetWindowSize(winW, winH)
 	ebiten.SetWindowTitle("First Game")
 
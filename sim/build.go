@@ -0,0 +1,215 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+const structuresLayer = "Structures"
+
+// Buildable is a structure the player can place in build mode, such as a
+// campfire or a fence. It is drawn with Tile on the map's Structures layer,
+// which collides, and costs Cost from the inventory.
+//
+// A buildable with Fuel is a fire: it is built burning for Fuel in-game
+// minutes, each item of fuel added keeps it going Fuel minutes longer up to
+// MaxFuel, and once it burns down it shows Unlit until refuelled. While it
+// burns it is the crafting Station; its light comes from the tile's light
+// properties.
+type Buildable struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Tile    uint32      `json:"tile"`
+	Cost    []ItemCount `json:"cost"`
+	Station string      `json:"station,omitempty"`
+	Fuel    int         `json:"fuel,omitempty"`
+	MaxFuel int         `json:"maxFuel,omitempty"`
+	Unlit   uint32      `json:"unlit,omitempty"`
+}
+
+// Structure is a buildable the player has placed.
+type Structure struct {
+	Buildable string `json:"buildable"`
+	Map       string `json:"map"`
+	Index     int    `json:"index"`
+	Fuel      int    `json:"fuel,omitempty"` // minutes a fire has left to burn
+}
+
+// BuildMode is open while the player picks and places structures. The
+// player can still walk and turn; the structure goes on the tile in front.
+type BuildMode struct {
+	Choice int // index into World.Buildables
+}
+
+// LoadBuildables reads the buildable registry from a JSON file.
+func LoadBuildables(path string) ([]*Buildable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Buildables []*Buildable `json:"buildables"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, b := range f.Buildables {
+		switch {
+		case b.ID == "":
+			return nil, fmt.Errorf("%s: buildable %q has no id", path, b.Name)
+		case seen[b.ID]:
+			return nil, fmt.Errorf("%s: duplicate buildable id %q", path, b.ID)
+		case b.Tile == 0:
+			return nil, fmt.Errorf("%s: buildable %q has no tile", path, b.ID)
+		case b.Fuel > 0 && b.Unlit == 0:
+			return nil, fmt.Errorf("%s: buildable %q burns but has no unlit tile", path, b.ID)
+		}
+		b.MaxFuel = max(b.MaxFuel, b.Fuel)
+		seen[b.ID] = true
+		if b.Name == "" {
+			b.Name = b.ID
+		}
+	}
+	return f.Buildables, nil
+}
+
+func (w *World) buildable(id string) *Buildable {
+	for _, b := range w.Buildables {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// SelectedBuildable is the structure build mode would place, or nil when
+// build mode is closed.
+func (w *World) SelectedBuildable() *Buildable {
+	if w.Building == nil || len(w.Buildables) == 0 {
+		return nil
+	}
+	return w.Buildables[w.Building.Choice%len(w.Buildables)]
+}
+
+// CanAfford reports whether the inventory holds the cost of b.
+func (w *World) CanAfford(b *Buildable) bool {
+	for _, c := range b.Cost {
+		if !w.HasItem(c.Item, c.Count) {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildTarget returns the tile in front of the player where build mode
+// would place a structure, and whether it is free to build on.
+func (w *World) BuildTarget() (TilePoint, bool) {
+	x, y := w.interactTile()
+	t := TilePoint{x, y}
+	return t, w.buildBlocked(t) == ""
+}
+
+// buildBlocked says why nothing can be built on a tile of the current map,
+// or returns "" if it is free.
+func (w *World) buildBlocked(t TilePoint) string {
+	const cant = "You can't build there."
+	if t.X < 0 || t.Y < 0 || t.X >= w.Map.Width || t.Y >= w.Map.Height || w.layer(structuresLayer) == nil {
+		return cant
+	}
+	idx := t.Y*w.Map.Width + t.X
+	if _, ok := w.portalAt(t); ok || w.collidesAt(idx) || w.standingOn(w.MapName, t) {
+		return cant
+	}
+	if w.plots[tileRef{w.MapName, idx}] != nil {
+		return "That's farmland."
+	}
+	return ""
+}
+
+// stepBuild handles opening, closing and acting in build mode. It reports
+// whether build mode is open, in which case the player may only move.
+func (w *World) stepBuild(in Input) bool {
+	ready := w.Tick-w.lastBuild > inputDelayTicks
+	if w.Building == nil {
+		// Not over the inventory, where space still has to close it
+		if in.Build && ready && !w.InventoryOpen && len(w.Buildables) > 0 {
+			w.Building = &BuildMode{}
+			w.lastBuild = w.Tick
+			return true
+		}
+		return false
+	}
+	if !ready {
+		return true
+	}
+	switch {
+	case in.Build || in.Back:
+		w.Building = nil
+		w.lastChatEnd = w.Tick // the key that closed it shouldn't open anything
+	case in.Next:
+		w.Building.Choice = (w.Building.Choice + 1) % len(w.Buildables)
+	case in.Action:
+		w.place(w.SelectedBuildable())
+	default:
+		return true
+	}
+	w.lastBuild = w.Tick
+	return w.Building != nil
+}
+
+// place builds b on the tile in front of the player if it is free and the
+// player can pay for it.
+func (w *World) place(b *Buildable) {
+	t, _ := w.BuildTarget()
+	if msg := w.buildBlocked(t); msg != "" {
+		w.notify(msg)
+		return
+	}
+	if !w.CanAfford(b) {
+		w.notify("Not enough materials for a " + b.Name + ".")
+		return
+	}
+	idx := t.Y*w.Map.Width + t.X
+	if err := w.SetTile(structuresLayer, idx, b.Tile); err != nil {
+		return
+	}
+	for _, c := range b.Cost {
+		w.RemoveItem(c.Item, c.Count)
+	}
+	if w.structures == nil {
+		w.structures = map[tileRef]*Structure{}
+	}
+	w.structures[tileRef{w.MapName, idx}] = &Structure{Buildable: b.ID, Map: w.MapName, Index: idx, Fuel: b.Fuel}
+}
+
+// Structures lists every placed structure, sorted for stable saves.
+func (w *World) Structures() []Structure {
+	list := make([]Structure, 0, len(w.structures))
+	for _, s := range w.structures {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Map != list[j].Map {
+			return list[i].Map < list[j].Map
+		}
+		return list[i].Index < list[j].Index
+	})
+	return list
+}
+
+// validateBuildables checks that every cost is a registered item.
+func (w *World) validateBuildables() error {
+	for _, b := range w.Buildables {
+		items := make([]string, len(b.Cost))
+		for i, c := range b.Cost {
+			items[i] = c.Item
+		}
+		if err := w.checkItems("buildable "+b.ID, items...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
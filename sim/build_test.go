@@ -0,0 +1,69 @@
+package sim
+
+import "testing"
+
+func TestBuildBlocked(t *testing.T) {
+	tests := []struct {
+		name  string
+		stand func(t *testing.T, w *World) TilePoint // where the player stands, facing down
+	}{
+		{"solid tile", func(t *testing.T, w *World) TilePoint {
+			for idx := w.Map.Width; idx < len(w.Map.Layers[0].Tiles); idx++ {
+				if w.collidesAt(idx) {
+					return TilePoint{idx % w.Map.Width, idx/w.Map.Width - 1}
+				}
+			}
+			t.Fatal("nothing solid on the start map")
+			return TilePoint{}
+		}},
+		{"off the map", func(_ *testing.T, w *World) TilePoint { return TilePoint{0, w.Map.Height - 1} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.AddToInventory("wood", 3)
+			w.Player.Pos = tt.stand(t, w).pos()
+			w.Player.Dir = 0
+			press(w, Input{Build: true})
+			if _, ok := w.BuildTarget(); ok {
+				t.Fatal("build target is free")
+			}
+			press(w, Input{Action: true})
+			if got := w.TakeNotice(); got != "You can't build there." {
+				t.Errorf("notice %q", got)
+			}
+			if len(w.Structures()) != 0 || w.CountItem("wood") != 3 {
+				t.Errorf("%d structures and %d wood after a blocked build", len(w.Structures()), w.CountItem("wood"))
+			}
+		})
+	}
+}
+
+func TestBuildBlocksAndSaves(t *testing.T) {
+	w := newTestWorld(t)
+	w.Player.Dir = 0
+	s := buildCampfire(t, w)
+
+	// The player can't walk onto it
+	from := w.tileIndex(w.Player.Pos)
+	for range 2 * TileSize {
+		w.Step(Input{Down: true})
+	}
+	if got := w.tileIndex(w.Player.Pos); got != from {
+		t.Errorf("walked from tile %d to %d, want stopped by the campfire at %d", from, got, s.Index)
+	}
+
+	got := newTestWorld(t)
+	if err := got.Restore(w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if list := got.Structures(); len(list) != 1 || list[0] != *s {
+		t.Fatalf("restored structures %+v, want %+v", list, *s)
+	}
+	if gid := layerTileGID(got.layer(structuresLayer).Tiles[s.Index]); gid != got.buildable("campfire").Tile {
+		t.Errorf("restored tile %d, want the campfire", gid)
+	}
+	if !got.collidesAt(s.Index) {
+		t.Error("restored campfire doesn't block")
+	}
+}
@@ -0,0 +1,118 @@
+package sim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Season is a quarter of the in-game year.
+type Season int
+
+const (
+	Spring Season = iota
+	Summer
+	Autumn
+	Winter
+)
+
+var seasonNames = [...]string{"spring", "summer", "autumn", "winter"}
+
+func (s Season) String() string {
+	return seasonNames[s]
+}
+
+func parseSeason(name string) (Season, bool) {
+	for i, n := range seasonNames {
+		if n == name {
+			return Season(i), true
+		}
+	}
+	return 0, false
+}
+
+const (
+	DaysPerWeek    = 7
+	WeeksPerSeason = 2
+	DaysPerSeason  = DaysPerWeek * WeeksPerSeason
+	DaysPerYear    = 4 * DaysPerSeason
+)
+
+var weekdays = [DaysPerWeek]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// seasonDaylight is when the sun is up in the middle of each season, in game
+// minutes. The days draw in and out smoothly between them.
+var seasonDaylight = [...]struct{ rise, set int }{
+	Spring: {6*60 + 30, 19*60 + 30},
+	Summer: {5*60 + 30, 20*60 + 30},
+	Autumn: {7 * 60, 18*60 + 30},
+	Winter: {8 * 60, 17 * 60},
+}
+
+// Date is a day of the in-game calendar. Every run starts on Monday, the
+// first day of spring of year 1.
+type Date struct {
+	Year   int
+	Season Season
+	Day    int // day of the season, from 1
+}
+
+// Week is the week of the season, from 1.
+func (d Date) Week() int {
+	return (d.Day-1)/DaysPerWeek + 1
+}
+
+// Weekday is the short name of the day of the week.
+func (d Date) Weekday() string {
+	return weekdays[(d.Day-1)%DaysPerWeek]
+}
+
+// String formats the date for the HUD, e.g. "Mon 3 Spring".
+func (d Date) String() string {
+	s := d.Season.String()
+	return fmt.Sprintf("%s %d %s", d.Weekday(), d.Day, strings.ToUpper(s[:1])+s[1:])
+}
+
+// dayAt is the day of the run, counting from 0, at the given number of
+// minutes into it. The clock the run started at is worked out from the
+// current time, so the calendar needs nothing saved of its own.
+func (w *World) dayAt(survived int) int {
+	start := ((w.GameMinutes-w.Stats.MinutesSurvived)%MinutesPerDay + MinutesPerDay) % MinutesPerDay
+	return (start + survived) / MinutesPerDay
+}
+
+func dateOf(day int) Date {
+	return Date{
+		Year:   day/DaysPerYear + 1,
+		Season: Season(day % DaysPerYear / DaysPerSeason),
+		Day:    day%DaysPerSeason + 1,
+	}
+}
+
+// Date is today's date.
+func (w *World) Date() Date {
+	return dateOf(w.dayAt(w.Stats.MinutesSurvived))
+}
+
+// Season is the current season.
+func (w *World) Season() Season {
+	return w.Date().Season
+}
+
+// Daylight returns today's sunrise and sunset in game minutes. They follow
+// seasonDaylight, blending into the neighbouring season's hours over the
+// first and second half of each season.
+func (w *World) Daylight() (rise, set int) {
+	d := w.Date()
+	t := (float64(d.Day) - 0.5) / DaysPerSeason // 0.5 in the middle of the season
+	other := (d.Season + 1) % 4
+	if t < 0.5 {
+		other = (d.Season + 3) % 4
+	}
+	k := 0.5 - t
+	if k < 0 {
+		k = -k
+	}
+	a, b := seasonDaylight[d.Season], seasonDaylight[other]
+	mix := func(x, y int) int { return x + int(float64(y-x)*k) }
+	return mix(a.rise, b.rise), mix(a.set, b.set)
+}
@@ -0,0 +1,87 @@
+package sim
+
+import "testing"
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		day  int // of the run, from 0
+		want string
+		year int
+		week int
+	}{
+		{0, "Mon 1 Spring", 1, 1},
+		{6, "Sun 7 Spring", 1, 1},
+		{7, "Mon 8 Spring", 1, 2},
+		{13, "Sun 14 Spring", 1, 2},
+		{14, "Mon 1 Summer", 1, 1},
+		{2*DaysPerSeason + 6, "Sun 7 Autumn", 1, 1},
+		{DaysPerYear - 1, "Sun 14 Winter", 1, 2},
+		{DaysPerYear, "Mon 1 Spring", 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			w := newTestWorld(t)
+			onDay(w, tt.day)
+			d := w.Date()
+			if d.String() != tt.want || d.Year != tt.year || d.Week() != tt.week {
+				t.Errorf("day %d: %s of year %d, week %d; want %s of year %d, week %d",
+					tt.day, d, d.Year, d.Week(), tt.want, tt.year, tt.week)
+			}
+		})
+	}
+}
+
+func TestDateRollover(t *testing.T) {
+	w := newTestWorld(t)
+	// A run that started at 08:00 on the last day of spring, a minute
+	// before midnight
+	w.Stats.MinutesSurvived = (DaysPerSeason-1)*MinutesPerDay + 16*60 - 1
+	w.GameMinutes = MinutesPerDay - 1
+	if d := w.Date(); d.Season != Spring || d.Day != DaysPerSeason {
+		t.Fatalf("before midnight: %s", d)
+	}
+	idle(w, ticksPerGameMinute)
+	if w.GameMinutes != 0 {
+		t.Fatalf("clock at %d, want midnight", w.GameMinutes)
+	}
+	if d := w.Date(); d.Season != Summer || d.Day != 1 || w.Season() != Summer {
+		t.Errorf("after midnight: %s, want the first of summer", d)
+	}
+}
+
+func TestDaylight(t *testing.T) {
+	length := func(day int) int {
+		w := newTestWorld(t)
+		onDay(w, day)
+		rise, set := w.Daylight()
+		if rise >= set {
+			t.Fatalf("day %d: sunrise %d after sunset %d", day, rise, set)
+		}
+		return set - rise
+	}
+	mid := DaysPerSeason / 2
+	spring, summer, winter := length(mid), length(DaysPerSeason+mid), length(3*DaysPerSeason+mid)
+	if !(winter < spring && spring < summer) {
+		t.Errorf("day lengths: spring %d, summer %d, winter %d minutes", spring, summer, winter)
+	}
+	// No jump where one season turns into the next, just the usual change
+	// from one day to the next of about a quarter of an hour
+	for day := DaysPerSeason; day <= DaysPerYear; day += DaysPerSeason {
+		if d := length(day) - length(day-1); d < -30 || d > 30 {
+			t.Errorf("days %d to %d: day length changes by %d minutes", day-1, day, d)
+		}
+	}
+}
+
+func TestSeasonalWeather(t *testing.T) {
+	w := newTestWorld(t)
+	for m := 0; m < DaysPerYear*MinutesPerDay; m += weatherSpell {
+		kind := weatherAt(w, m).Kind
+		switch season := w.Season(); {
+		case season == Winter && (kind == Rain || kind == Storm):
+			t.Fatalf("%v in winter, at %s", kind, w.Date())
+		case season != Winter && kind == Snow:
+			t.Fatalf("snow in %v, at %s", season, w.Date())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type ConversationNode struct {
+	ID      string
+	Text    string
+	Choices []ConversationChoice
+}
+
+type ConversationChoice struct {
+	Text       string
+	Next       *ConversationNode // nil ends the conversation
+	Conditions []Condition       // all must hold for the choice to be shown
+	Effects    []Effect          // applied in order when the choice is picked
+}
+
+// DialogueFile is the on-disk form of one conversation. Nodes are addressed
+// by ID and choices point at the ID of the node they lead to; a choice with
+// no "next" ends the conversation. A file belongs either to an NPC or to a
+// map interaction such as "fish" or "chop".
+type DialogueFile struct {
+	NPC         string         `json:"npc,omitempty"`
+	Interaction string         `json:"interaction,omitempty"`
+	Start       string         `json:"start"`
+	Nodes       []DialogueNode `json:"nodes"`
+}
+
+type DialogueNode struct {
+	ID      string           `json:"id"`
+	Text    string           `json:"text"`
+	Choices []DialogueChoice `json:"choices"`
+}
+
+type DialogueChoice struct {
+	Text       string      `json:"text"`
+	Next       string      `json:"next,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	Effects    []Effect    `json:"effects,omitempty"`
+}
+
+// LoadConversations reads every *.json dialogue file in dir and returns the
+// root node of each conversation keyed by NPC or interaction name.
+func LoadConversations(dir string) (map[string]*ConversationNode, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	convs := map[string]*ConversationNode{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f DialogueFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		root, err := f.Build()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, dup := convs[f.Name()]; dup {
+			return nil, fmt.Errorf("%s: duplicate conversation for %q", path, f.Name())
+		}
+		convs[f.Name()] = root
+	}
+	return convs, nil
+}
+
+// Build resolves node references into a ConversationNode graph. It reports
+// duplicate IDs, choices pointing at missing nodes and nodes that can never be
+// reached from the start node.
+func (f *DialogueFile) Build() (*ConversationNode, error) {
+	if (f.NPC == "") == (f.Interaction == "") {
+		return nil, errors.New("dialogue needs exactly one of npc or interaction")
+	}
+	nodes := map[string]*ConversationNode{}
+	for _, n := range f.Nodes {
+		if n.ID == "" {
+			return nil, fmt.Errorf("node with text %q has no id", n.Text)
+		}
+		if _, dup := nodes[n.ID]; dup {
+			return nil, fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		nodes[n.ID] = &ConversationNode{ID: n.ID, Text: n.Text}
+	}
+	start, ok := nodes[f.Start]
+	if !ok {
+		return nil, fmt.Errorf("start node %q does not exist", f.Start)
+	}
+
+	var errs []error
+	for _, n := range f.Nodes {
+		node := nodes[n.ID]
+		for _, c := range n.Choices {
+			choice := ConversationChoice{Text: c.Text, Effects: c.Effects}
+			for _, cond := range c.Conditions {
+				if err := cond.compile(); err != nil {
+					errs = append(errs, fmt.Errorf("node %q choice %q: %w", n.ID, c.Text, err))
+				}
+				choice.Conditions = append(choice.Conditions, cond)
+			}
+			for _, e := range c.Effects {
+				if err := e.validate(); err != nil {
+					errs = append(errs, fmt.Errorf("node %q choice %q: %w", n.ID, c.Text, err))
+				}
+			}
+			if c.Next != "" {
+				next, ok := nodes[c.Next]
+				if !ok {
+					errs = append(errs, fmt.Errorf("node %q choice %q points to missing node %q", n.ID, c.Text, c.Next))
+				}
+				choice.Next = next
+			}
+			node.Choices = append(node.Choices, choice)
+		}
+	}
+
+	// Walk the graph from the start node to find orphans
+	reached := map[*ConversationNode]bool{}
+	queue := []*ConversationNode{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if reached[node] {
+			continue
+		}
+		reached[node] = true
+		for _, c := range node.Choices {
+			if c.Next != nil {
+				queue = append(queue, c.Next)
+			}
+		}
+	}
+	for _, n := range f.Nodes {
+		if !reached[nodes[n.ID]] {
+			errs = append(errs, fmt.Errorf("node %q is unreachable from start node %q", n.ID, f.Start))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return start, nil
+}
+
+// Name is the key the conversation is stored under.
+func (f *DialogueFile) Name() string {
+	if f.NPC != "" {
+		return f.NPC
+	}
+	return f.Interaction
+}
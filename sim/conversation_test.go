@@ -0,0 +1,96 @@
+package sim
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDialogueBuild(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  string // part of the error, "" when the file is fine
+	}{
+		{"valid", `{"npc": "Kid", "start": "a", "nodes": [
+			{"id": "a", "text": "Hi", "choices": [{"text": "More", "next": "b"}, {"text": "Bye"}]},
+			{"id": "b", "text": "Loop", "choices": [{"text": "Back", "next": "a"}]}]}`, ""},
+		{"npc and interaction", `{"npc": "Kid", "interaction": "fish", "start": "a", "nodes": [{"id": "a"}]}`, "exactly one of npc or interaction"},
+		{"neither npc nor interaction", `{"start": "a", "nodes": [{"id": "a"}]}`, "exactly one of npc or interaction"},
+		{"node without id", `{"npc": "Kid", "start": "a", "nodes": [{"id": "a"}, {"text": "Lost"}]}`, `node with text "Lost" has no id`},
+		{"duplicate id", `{"npc": "Kid", "start": "a", "nodes": [{"id": "a"}, {"id": "a"}]}`, `duplicate node id "a"`},
+		{"missing start", `{"npc": "Kid", "start": "z", "nodes": [{"id": "a"}]}`, `start node "z" does not exist`},
+		{"dangling next", `{"npc": "Kid", "start": "a", "nodes": [
+			{"id": "a", "choices": [{"text": "Go", "next": "nowhere"}]}]}`, `points to missing node "nowhere"`},
+		{"unreachable node", `{"npc": "Kid", "start": "a", "nodes": [
+			{"id": "a", "choices": [{"text": "Bye"}]},
+			{"id": "orphan", "choices": [{"text": "Back", "next": "a"}]}]}`, `node "orphan" is unreachable`},
+		{"bad condition", `{"npc": "Kid", "start": "a", "nodes": [
+			{"id": "a", "choices": [{"text": "Go", "conditions": [{"type": "stat", "stat": "luck"}]}]}]}`, `unknown stat "luck"`},
+		{"bad effect", `{"npc": "Kid", "start": "a", "nodes": [
+			{"id": "a", "choices": [{"text": "Go", "effects": [{"type": "teleport"}]}]}]}`, `unknown effect type "teleport"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f DialogueFile
+			if err := json.Unmarshal([]byte(tt.file), &f); err != nil {
+				t.Fatal(err)
+			}
+			root, err := f.Build()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("Build: %v", err)
+			case tt.err == "" && root.ID != f.Start:
+				t.Errorf("root = %q, want %q", root.ID, f.Start)
+			case tt.err != "" && err == nil:
+				t.Fatalf("Build succeeded, want error %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error = %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadConversations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"two conversations", map[string]string{
+			"kid.json":  `{"npc": "Kid", "start": "a", "nodes": [{"id": "a"}]}`,
+			"fish.json": `{"interaction": "fish", "start": "a", "nodes": [{"id": "a"}]}`,
+		}, ""},
+		{"duplicate conversation", map[string]string{
+			"kid.json":   `{"npc": "Kid", "start": "a", "nodes": [{"id": "a"}]}`,
+			"kid_2.json": `{"npc": "Kid", "start": "b", "nodes": [{"id": "b"}]}`,
+		}, `kid_2.json: duplicate conversation for "Kid"`},
+		{"broken json", map[string]string{"kid.json": `{"npc": "Kid",`}, "kid.json: unexpected end of JSON input"},
+		{"broken graph", map[string]string{
+			"kid.json": `{"npc": "Kid", "start": "a", "nodes": [{"id": "a", "choices": [{"text": "Go", "next": "b"}]}]}`,
+		}, `kid.json: node "a" choice "Go" points to missing node "b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			convs, err := LoadConversations(dir)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("LoadConversations: %v", err)
+			case tt.err == "" && len(convs) != len(tt.files):
+				t.Errorf("loaded %d conversations, want %d", len(convs), len(tt.files))
+			case tt.err != "" && err == nil:
+				t.Fatalf("LoadConversations succeeded, want error %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error = %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
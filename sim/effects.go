@@ -0,0 +1,262 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Condition gates a conversation choice. Supported types:
+//
+//	hasItem  item, count        player carries at least count of item
+//	time     from, to ("HH:MM") clock is inside the range, may wrap midnight
+//	stat     stat, min, max     health/social/hunger is within [min, max]
+//	flag     flag               story flag is set
+//	season   seasons            it is one of the seasons, e.g. ["summer", "autumn"]
+//	day      min, max           day of the season is within [min, max]
+//
+// Not inverts the result.
+type Condition struct {
+	Type    string   `json:"type"`
+	Item    string   `json:"item,omitempty"`
+	Count   int      `json:"count,omitempty"`
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Stat    string   `json:"stat,omitempty"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Flag    string   `json:"flag,omitempty"`
+	Seasons []string `json:"seasons,omitempty"`
+	Not     bool     `json:"not,omitempty"`
+
+	fromMin, toMin int
+	seasons        []Season
+}
+
+// Effect is applied when a choice is picked. Supported types:
+//
+//	addItem     item, count
+//	removeItem  item, count
+//	stat        stat, amount    add amount to health/social/hunger, clamped to 0..1
+//	setFlag     flag
+//	clearFlag   flag
+//	removeTile  layer           clear the tile the interaction started from;
+//	                            layer defaults to the interaction's layer
+//	harvest     layer           harvest the resource node the interaction
+//	                            started from, see ResourceDef; if there is
+//	                            none the choice's later effects are skipped
+//	                            and the conversation ends
+//	openShop                    open the trade window of the NPC being talked to
+//	fish                        cast a line into the water the interaction
+//	                            started from, see Fishing
+//	refuel      item, count     burn count of item in the fire the interaction
+//	                            started from, see Buildable
+type Effect struct {
+	Type   string  `json:"type"`
+	Item   string  `json:"item,omitempty"`
+	Count  int     `json:"count,omitempty"`
+	Stat   string  `json:"stat,omitempty"`
+	Amount float64 `json:"amount,omitempty"`
+	Flag   string  `json:"flag,omitempty"`
+	Layer  string  `json:"layer,omitempty"`
+}
+
+// interactTarget is the map tile an NPC-less interaction was started from.
+type interactTarget struct {
+	layer string
+	index int
+}
+
+func (c *Condition) compile() error {
+	switch c.Type {
+	case "hasItem":
+		if c.Item == "" {
+			return fmt.Errorf("hasItem condition needs an item")
+		}
+		if c.Count == 0 {
+			c.Count = 1
+		}
+	case "time":
+		var err error
+		if c.fromMin, err = parseClock(c.From); err != nil {
+			return err
+		}
+		if c.toMin, err = parseClock(c.To); err != nil {
+			return err
+		}
+	case "stat":
+		if !validStat(c.Stat) {
+			return fmt.Errorf("unknown stat %q", c.Stat)
+		}
+	case "flag":
+		if c.Flag == "" {
+			return fmt.Errorf("flag condition needs a flag")
+		}
+	case "season":
+		if len(c.Seasons) == 0 {
+			return fmt.Errorf("season condition needs seasons")
+		}
+		c.seasons = nil
+		for _, name := range c.Seasons {
+			s, ok := parseSeason(name)
+			if !ok {
+				return fmt.Errorf("unknown season %q", name)
+			}
+			c.seasons = append(c.seasons, s)
+		}
+	case "day":
+		if c.Min == nil && c.Max == nil {
+			return fmt.Errorf("day condition needs a min or max")
+		}
+	default:
+		return fmt.Errorf("unknown condition type %q", c.Type)
+	}
+	return nil
+}
+
+func (e *Effect) validate() error {
+	switch e.Type {
+	case "addItem", "removeItem", "refuel":
+		if e.Item == "" {
+			return fmt.Errorf("%s effect needs an item", e.Type)
+		}
+	case "stat":
+		if !validStat(e.Stat) {
+			return fmt.Errorf("unknown stat %q", e.Stat)
+		}
+	case "setFlag", "clearFlag":
+		if e.Flag == "" {
+			return fmt.Errorf("%s effect needs a flag", e.Type)
+		}
+	case "removeTile", "harvest", "openShop", "fish":
+	default:
+		return fmt.Errorf("unknown effect type %q", e.Type)
+	}
+	return nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validStat(name string) bool {
+	return name == "health" || name == "social" || name == "hunger"
+}
+
+func (w *World) stat(name string) *float64 {
+	switch name {
+	case "health":
+		return &w.Health
+	case "social":
+		return &w.Social
+	case "hunger":
+		return &w.Hunger
+	}
+	return nil
+}
+
+// Check reports whether the condition holds in the current world.
+func (w *World) Check(c Condition) bool {
+	var ok bool
+	switch c.Type {
+	case "hasItem":
+		ok = w.HasItem(c.Item, c.Count)
+	case "time":
+		if c.fromMin <= c.toMin {
+			ok = w.GameMinutes >= c.fromMin && w.GameMinutes < c.toMin
+		} else {
+			ok = w.GameMinutes >= c.fromMin || w.GameMinutes < c.toMin
+		}
+	case "stat":
+		v := *w.stat(c.Stat)
+		ok = (c.Min == nil || v >= *c.Min) && (c.Max == nil || v <= *c.Max)
+	case "flag":
+		ok = w.Flags[c.Flag]
+	case "season":
+		ok = slices.Contains(c.seasons, w.Season())
+	case "day":
+		v := float64(w.Date().Day)
+		ok = (c.Min == nil || v >= *c.Min) && (c.Max == nil || v <= *c.Max)
+	}
+	return ok != c.Not
+}
+
+// Apply performs an effect on the world. It reports false when a harvest
+// found nothing to harvest, in which case the rest of the choice's effects
+// should be skipped and the conversation ended rather than moved on.
+func (w *World) Apply(e Effect) bool {
+	switch e.Type {
+	case "addItem":
+		n := max(e.Count, 1)
+		w.Stats.ItemsGathered += n - w.AddToInventory(e.Item, n)
+	case "removeItem":
+		w.RemoveItem(e.Item, max(e.Count, 1))
+	case "stat":
+		v := w.stat(e.Stat)
+		*v = min(max(*v+e.Amount, 0), 1)
+	case "setFlag":
+		if w.Flags == nil {
+			w.Flags = map[string]bool{}
+		}
+		w.Flags[e.Flag] = true
+	case "clearFlag":
+		delete(w.Flags, e.Flag)
+	case "removeTile":
+		if w.target == nil {
+			return true
+		}
+		layer := e.Layer
+		if layer == "" {
+			layer = w.target.layer
+		}
+		w.SetTile(layer, w.target.index, 0)
+	case "harvest":
+		if w.target == nil {
+			return false
+		}
+		layer := e.Layer
+		if layer == "" {
+			layer = w.target.layer
+		}
+		return w.harvest(layer, w.target.index)
+	case "openShop":
+		w.openShop()
+	case "fish":
+		w.startFishing()
+	case "refuel":
+		w.refuel(e.Item, max(e.Count, 1))
+	}
+	return true
+}
+
+// Available reports whether every condition on the choice holds.
+func (w *World) Available(choice ConversationChoice) bool {
+	for _, c := range choice.Conditions {
+		if !w.Check(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// VisibleChoices lists the choices of the current node whose conditions hold.
+// A lone "Goodbye" is offered when nothing else is available.
+func (w *World) VisibleChoices() []ConversationChoice {
+	if w.ConvNode == nil {
+		return nil
+	}
+	var choices []ConversationChoice
+	for _, c := range w.ConvNode.Choices {
+		if w.Available(c) {
+			choices = append(choices, c)
+		}
+	}
+	if len(choices) == 0 {
+		choices = []ConversationChoice{{Text: "Goodbye", Next: nil}}
+	}
+	return choices
+}
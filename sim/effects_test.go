@@ -0,0 +1,174 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// findResource returns the base tile of the first node of a resource on a
+// layer of the current map.
+func findResource(t *testing.T, w *World, layerName, id string) int {
+	t.Helper()
+	layer := w.layer(layerName)
+	for idx := range layer.Tiles {
+		if def, base, _ := w.resourceAt(layer, idx); def != nil && def.ID == id && base == idx {
+			return idx
+		}
+	}
+	t.Fatalf("no %s on %s of %s", id, layerName, w.MapName)
+	return 0
+}
+
+// onDay sets the calendar to a day of the run, counting from 0, keeping
+// the clock where it is.
+func onDay(w *World, day int) {
+	w.Stats.MinutesSurvived = day * MinutesPerDay
+}
+
+func TestCheck(t *testing.T) {
+	num := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name  string
+		cond  Condition
+		setup func(w *World)
+		want  bool
+	}{
+		{"hasItem none", Condition{Type: "hasItem", Item: "wood"}, nil, false},
+		{"hasItem one", Condition{Type: "hasItem", Item: "wood"}, func(w *World) { w.AddToInventory("wood", 1) }, true},
+		{"hasItem too few", Condition{Type: "hasItem", Item: "wood", Count: 3}, func(w *World) { w.AddToInventory("wood", 2) }, false},
+		{"hasItem enough", Condition{Type: "hasItem", Item: "wood", Count: 3}, func(w *World) { w.AddToInventory("wood", 3) }, true},
+		{"time inside", Condition{Type: "time", From: "08:00", To: "12:00"}, nil, true},
+		{"time at end", Condition{Type: "time", From: "06:00", To: "08:00"}, nil, false},
+		{"time before", Condition{Type: "time", From: "09:00", To: "12:00"}, nil, false},
+		{"time wraps, evening", Condition{Type: "time", From: "22:00", To: "04:00"}, func(w *World) { w.GameMinutes = 23 * 60 }, true},
+		{"time wraps, small hours", Condition{Type: "time", From: "22:00", To: "04:00"}, func(w *World) { w.GameMinutes = 60 }, true},
+		{"time wraps, daytime", Condition{Type: "time", From: "22:00", To: "04:00"}, nil, false},
+		{"stat in range", Condition{Type: "stat", Stat: "hunger", Min: num(0.2), Max: num(0.6)}, func(w *World) { w.Hunger = 0.4 }, true},
+		{"stat below min", Condition{Type: "stat", Stat: "health", Min: num(0.5)}, func(w *World) { w.Health = 0.3 }, false},
+		{"stat above max", Condition{Type: "stat", Stat: "social", Max: num(0.5)}, nil, false},
+		{"flag unset", Condition{Type: "flag", Flag: "met_kid"}, nil, false},
+		{"flag set", Condition{Type: "flag", Flag: "met_kid"}, func(w *World) { w.Flags = map[string]bool{"met_kid": true} }, true},
+		{"season now", Condition{Type: "season", Seasons: []string{"spring"}}, nil, true},
+		{"season later", Condition{Type: "season", Seasons: []string{"summer", "autumn"}}, func(w *World) { onDay(w, 2*DaysPerSeason) }, true},
+		{"season not listed", Condition{Type: "season", Seasons: []string{"summer", "autumn"}}, func(w *World) { onDay(w, 3*DaysPerSeason) }, false},
+		{"day in range", Condition{Type: "day", Min: num(3), Max: num(5)}, func(w *World) { onDay(w, DaysPerSeason+3) }, true},
+		{"day past max", Condition{Type: "day", Max: num(5)}, func(w *World) { onDay(w, 5) }, false},
+		{"not unset flag", Condition{Type: "flag", Flag: "met_kid", Not: true}, nil, true},
+		{"not held item", Condition{Type: "hasItem", Item: "wood", Not: true}, func(w *World) { w.AddToInventory("wood", 1) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			if tt.setup != nil {
+				tt.setup(w)
+			}
+			c := tt.cond
+			if err := c.compile(); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Check(c); got != tt.want {
+				t.Errorf("Check = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		effects []Effect
+		setup   func(w *World) *interactTarget
+		check   func(t *testing.T, w *World, target *interactTarget)
+	}{
+		{"addItem", []Effect{{Type: "addItem", Item: "wood", Count: 2}}, nil, func(t *testing.T, w *World, _ *interactTarget) {
+			if n := w.CountItem("wood"); n != 2 || w.Stats.ItemsGathered != 2 {
+				t.Errorf("%d wood, %d gathered, want 2", n, w.Stats.ItemsGathered)
+			}
+		}},
+		{"removeItem", []Effect{{Type: "removeItem", Item: "wood"}}, func(w *World) *interactTarget {
+			w.AddToInventory("wood", 3)
+			return nil
+		}, func(t *testing.T, w *World, _ *interactTarget) {
+			if n := w.CountItem("wood"); n != 2 {
+				t.Errorf("%d wood left, want 2", n)
+			}
+		}},
+		{"stat clamped", []Effect{{Type: "stat", Stat: "hunger", Amount: 0.5}, {Type: "stat", Stat: "health", Amount: -2}}, func(w *World) *interactTarget {
+			w.Hunger = 0.8
+			return nil
+		}, func(t *testing.T, w *World, _ *interactTarget) {
+			// Hunger drains a little while the key is held
+			if math.Abs(w.Hunger-1) > 10*drainPerMinute || w.Health != 0 {
+				t.Errorf("hunger %v, health %v, want 1 and 0", w.Hunger, w.Health)
+			}
+		}},
+		{"setFlag and clearFlag", []Effect{{Type: "setFlag", Flag: "a"}, {Type: "setFlag", Flag: "b"}, {Type: "clearFlag", Flag: "a"}}, nil, func(t *testing.T, w *World, _ *interactTarget) {
+			if w.Flags["a"] || !w.Flags["b"] {
+				t.Errorf("flags %v, want only b", w.Flags)
+			}
+		}},
+		{"removeTile", []Effect{{Type: "removeTile"}}, func(w *World) *interactTarget {
+			return &interactTarget{layer: "Resources", index: findResource(t, w, "Resources", "rock")}
+		}, func(t *testing.T, w *World, target *interactTarget) {
+			if hasTile(w.layer("Resources"), target.index) {
+				t.Error("tile still there")
+			}
+		}},
+		{"harvest", []Effect{{Type: "harvest"}, {Type: "addItem", Item: "stone", Count: 2}}, func(w *World) *interactTarget {
+			return &interactTarget{layer: "Resources", index: findResource(t, w, "Resources", "rock")}
+		}, func(t *testing.T, w *World, target *interactTarget) {
+			if len(w.Regrowing()) != 1 || w.CountItem("stone") != 2 {
+				t.Errorf("%d regrowing, %d stone, want 1 and 2", len(w.Regrowing()), w.CountItem("stone"))
+			}
+		}},
+		{"harvest nothing", []Effect{{Type: "harvest"}, {Type: "addItem", Item: "stone", Count: 2}}, func(w *World) *interactTarget {
+			idx := findResource(t, w, "Resources", "rock")
+			w.SetTile("Resources", idx, 0)
+			return &interactTarget{layer: "Resources", index: idx}
+		}, func(t *testing.T, w *World, target *interactTarget) {
+			if n := w.CountItem("stone"); n != 0 {
+				t.Errorf("got %d stone from an empty tile", n)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			var target *interactTarget
+			if tt.setup != nil {
+				target = tt.setup(w)
+			}
+			node := &ConversationNode{ID: "test", Choices: []ConversationChoice{{Text: "Do it", Effects: tt.effects}}}
+			w.startAction(node, target)
+			press(w, Input{Action: true})
+			if w.Chatting {
+				t.Fatal("choice was not picked")
+			}
+			tt.check(t, w, target)
+		})
+	}
+}
+
+func TestFailedEffectEndsChat(t *testing.T) {
+	for _, empty := range []bool{false, true} {
+		w := newTestWorld(t)
+		idx := findResource(t, w, "Resources", "rock")
+		if empty {
+			w.SetTile("Resources", idx, 0)
+		}
+		after := &ConversationNode{ID: "after", Choices: []ConversationChoice{{Text: "Done"}}}
+		node := &ConversationNode{ID: "test", Choices: []ConversationChoice{{
+			Text:    "Mine it",
+			Next:    after,
+			Effects: []Effect{{Type: "harvest"}, {Type: "addItem", Item: "stone", Count: 2}},
+		}}}
+		w.startAction(node, &interactTarget{layer: "Resources", index: idx})
+		press(w, Input{Action: true})
+		switch {
+		case empty && (w.Chatting || w.CountItem("stone") != 0):
+			t.Errorf("nothing to harvest: chatting %v with %d stone, want the chat over", w.Chatting, w.CountItem("stone"))
+		case !empty && (w.ConvNode != after || w.CountItem("stone") != 2):
+			t.Errorf("harvested: on node %v with %d stone, want after and 2", w.ConvNode, w.CountItem("stone"))
+		}
+	}
+}
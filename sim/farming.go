@@ -0,0 +1,245 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Tools the farming actions need in the inventory.
+const (
+	hoeItem          = "hoe"
+	wateringCanItem  = "watering_can"
+	cropsLayer       = "Crops"
+	baseGroundLayer  = "Base ground"
+	tillableProperty = "tillable"
+)
+
+// FarmDef holds the tiles of tilled ground and the crops that can be grown.
+// Soil replaces the Base ground tile when the player tills it, WetSoil when
+// it has been watered today.
+type FarmDef struct {
+	Soil    uint32     `json:"soil"`
+	WetSoil uint32     `json:"wetSoil"`
+	Crops   []*CropDef `json:"crops"`
+}
+
+// CropDef is a plant grown from Seed. It is drawn on the Crops layer with one
+// GID per growth stage, seedling first, and moves up a stage after
+// DaysPerStage watered days. Harvesting the last stage gives Count Produce.
+type CropDef struct {
+	ID           string   `json:"id"`
+	Seed         string   `json:"seed"`
+	Produce      string   `json:"produce"`
+	Count        int      `json:"count,omitempty"`
+	Stages       []uint32 `json:"stages"`
+	DaysPerStage int      `json:"daysPerStage"`
+}
+
+// Plot is a tilled tile and what grows on it.
+type Plot struct {
+	Map     string `json:"map"`
+	Index   int    `json:"index"`
+	Crop    string `json:"crop,omitempty"`
+	Stage   int    `json:"stage,omitempty"`
+	Days    int    `json:"days,omitempty"` // watered days spent in this stage
+	Watered bool   `json:"watered,omitempty"`
+}
+
+// LoadFarm reads the soil tiles and crop definitions from a JSON file.
+func LoadFarm(path string) (*FarmDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &FarmDef{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Soil == 0 || f.WetSoil == 0 {
+		return nil, fmt.Errorf("%s: soil and wetSoil tiles are required", path)
+	}
+	seen := map[string]bool{}
+	for _, c := range f.Crops {
+		switch {
+		case c.ID == "":
+			return nil, fmt.Errorf("%s: crop has no id", path)
+		case seen[c.ID]:
+			return nil, fmt.Errorf("%s: duplicate crop id %q", path, c.ID)
+		case c.Seed == "" || c.Produce == "":
+			return nil, fmt.Errorf("%s: crop %q needs a seed and produce", path, c.ID)
+		case len(c.Stages) == 0:
+			return nil, fmt.Errorf("%s: crop %q has no stages", path, c.ID)
+		}
+		seen[c.ID] = true
+		c.Count = max(c.Count, 1)
+		c.DaysPerStage = max(c.DaysPerStage, 1)
+	}
+	return f, nil
+}
+
+func (w *World) crop(id string) *CropDef {
+	if w.Farm == nil {
+		return nil
+	}
+	for _, c := range w.Farm.Crops {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// seedToPlant is the first seed in the inventory that grows into a crop.
+func (w *World) seedToPlant() *CropDef {
+	if w.Farm == nil {
+		return nil
+	}
+	for y := range w.Inventory {
+		for x := range w.Inventory[y] {
+			slot := w.Inventory[y][x]
+			if slot.Count == 0 {
+				continue
+			}
+			for _, c := range w.Farm.Crops {
+				if c.Seed == slot.Item {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// farm works the tile the player faces, doing the first thing that applies:
+// harvest a ripe crop, water a thirsty one, plant a seed in bare soil or till
+// the ground.
+func (w *World) farm() {
+	x, y := w.interactTile()
+	if w.Farm == nil || x < 0 || y < 0 || x >= w.Map.Width || y >= w.Map.Height {
+		return
+	}
+	idx := y*w.Map.Width + x
+	plot := w.plots[tileRef{w.MapName, idx}]
+	if plot == nil {
+		w.till(idx)
+		return
+	}
+	crop := w.crop(plot.Crop)
+	switch {
+	case crop == nil:
+		w.plant(plot)
+	case plot.Stage == len(crop.Stages)-1:
+		w.harvestCrop(plot, crop)
+	case !plot.Watered:
+		w.water(plot)
+	default:
+		w.notify(fmt.Sprintf("The %s is watered for today.", w.ItemName(crop.Produce)))
+	}
+}
+
+func (w *World) till(idx int) {
+	_, v, _ := w.topProp(idx, tillableProperty)
+	if ok, _ := strconv.ParseBool(v); !ok || w.collidesAt(idx) || w.layer(cropsLayer) == nil {
+		return
+	}
+	if !w.HasItem(hoeItem, 1) {
+		w.notify("You need a hoe to till the ground.")
+		return
+	}
+	if w.SetTile(baseGroundLayer, idx, w.Farm.Soil) != nil {
+		return
+	}
+	if w.plots == nil {
+		w.plots = map[tileRef]*Plot{}
+	}
+	w.plots[tileRef{w.MapName, idx}] = &Plot{Map: w.MapName, Index: idx}
+}
+
+func (w *World) plant(plot *Plot) {
+	crop := w.seedToPlant()
+	if crop == nil {
+		w.notify("You have no seeds to plant.")
+		return
+	}
+	w.RemoveItem(crop.Seed, 1)
+	*plot = Plot{Map: plot.Map, Index: plot.Index, Crop: crop.ID, Watered: plot.Watered}
+	w.setTileOn(plot.Map, cropsLayer, plot.Index, crop.Stages[0])
+}
+
+func (w *World) water(plot *Plot) {
+	if !w.HasItem(wateringCanItem, 1) {
+		w.notify("You need a watering can.")
+		return
+	}
+	w.wetPlot(plot)
+}
+
+// wetPlot waters a plot for the rest of the day.
+func (w *World) wetPlot(plot *Plot) {
+	plot.Watered = true
+	w.setTileOn(plot.Map, baseGroundLayer, plot.Index, w.Farm.WetSoil)
+}
+
+func (w *World) harvestCrop(plot *Plot, crop *CropDef) {
+	if !w.CanAdd(crop.Produce, crop.Count) {
+		w.notify("No room in your inventory.")
+		return
+	}
+	w.AddToInventory(crop.Produce, crop.Count)
+	w.Stats.ItemsGathered += crop.Count
+	*plot = Plot{Map: plot.Map, Index: plot.Index, Watered: plot.Watered}
+	w.setTileOn(plot.Map, cropsLayer, plot.Index, 0)
+}
+
+// stepFarm runs once per in-game minute. At midnight every watered crop
+// counts a day towards its next stage and all soil dries out.
+func (w *World) stepFarm() {
+	if w.GameMinutes != 0 || w.Farm == nil {
+		return
+	}
+	for _, plot := range w.plots {
+		if crop := w.crop(plot.Crop); crop != nil && plot.Watered && plot.Stage < len(crop.Stages)-1 {
+			plot.Days++
+			if plot.Days >= crop.DaysPerStage {
+				plot.Stage++
+				plot.Days = 0
+				w.setTileOn(plot.Map, cropsLayer, plot.Index, crop.Stages[plot.Stage])
+			}
+		}
+		if plot.Watered {
+			plot.Watered = false
+			w.setTileOn(plot.Map, baseGroundLayer, plot.Index, w.Farm.Soil)
+		}
+	}
+}
+
+// Plots lists every tilled tile, sorted for stable saves.
+func (w *World) Plots() []Plot {
+	list := make([]Plot, 0, len(w.plots))
+	for _, p := range w.plots {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Map != list[j].Map {
+			return list[i].Map < list[j].Map
+		}
+		return list[i].Index < list[j].Index
+	})
+	return list
+}
+
+// validateFarm checks that seeds and produce are registered items.
+func (w *World) validateFarm() error {
+	if w.Farm == nil {
+		return nil
+	}
+	for _, c := range w.Farm.Crops {
+		if err := w.checkItems("crop "+c.ID, c.Seed, c.Produce); err != nil {
+			return err
+		}
+	}
+	return w.checkItems("farming tools", hoeItem, wateringCanItem)
+}
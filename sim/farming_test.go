@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"strconv"
+	"testing"
+)
+
+// faceTillable stands the player above the first tillable tile of the current
+// map, facing it, and returns its index.
+func faceTillable(t *testing.T, w *World) int {
+	t.Helper()
+	for idx := w.Map.Width; idx < w.Map.Width*w.Map.Height; idx++ {
+		_, v, _ := w.topProp(idx, tillableProperty)
+		if ok, _ := strconv.ParseBool(v); ok && !w.collidesAt(idx) {
+			w.Player.Pos = TilePoint{idx % w.Map.Width, idx/w.Map.Width - 1}.pos()
+			w.Player.Dir = 0
+			return idx
+		}
+	}
+	t.Fatalf("nothing tillable on %s", w.MapName)
+	return 0
+}
+
+func TestFarming(t *testing.T) {
+	w := newTestWorld(t)
+	idx := faceTillable(t, w)
+	ground, crops := w.layer(baseGroundLayer), w.layer(cropsLayer)
+	grass := layerTileGID(ground.Tiles[idx])
+	tomato := w.crop("tomato")
+
+	// use presses F and checks what the tile and plot look like afterwards
+	use := func(step string, notice string, soil, crop uint32) *Plot {
+		t.Helper()
+		press(w, Input{Use: true})
+		if got := w.TakeNotice(); got != notice {
+			t.Fatalf("%s: notice %q, want %q", step, got, notice)
+		}
+		if got := layerTileGID(ground.Tiles[idx]); got != soil {
+			t.Fatalf("%s: ground %d, want %d", step, got, soil)
+		}
+		if got := layerTileGID(crops.Tiles[idx]); got != crop {
+			t.Fatalf("%s: crop tile %d, want %d", step, got, crop)
+		}
+		return w.plots[tileRef{w.MapName, idx}]
+	}
+
+	if use("no hoe", "You need a hoe to till the ground.", grass, 0) != nil {
+		t.Fatal("tilled without a hoe")
+	}
+	w.AddToInventory(hoeItem, 1)
+	plot := use("till", "", w.Farm.Soil, 0)
+	if plot == nil {
+		t.Fatal("no plot after tilling")
+	}
+	use("no seeds", "You have no seeds to plant.", w.Farm.Soil, 0)
+	w.AddToInventory("tomato_seeds", 2)
+	use("plant", "", w.Farm.Soil, tomato.Stages[0])
+	if n := w.CountItem("tomato_seeds"); n != 1 || plot.Crop != "tomato" {
+		t.Fatalf("%d seeds left, crop %q, want 1 tomato", n, plot.Crop)
+	}
+	use("no can", "You need a watering can.", w.Farm.Soil, tomato.Stages[0])
+	w.AddToInventory(wateringCanItem, 1)
+	use("water", "", w.Farm.WetSoil, tomato.Stages[0])
+	use("watered", "The Tomato is watered for today.", w.Farm.WetSoil, tomato.Stages[0])
+
+	// A watered day grows it a stage and dries the soil
+	passMidnight(w)
+	if plot.Stage != 1 || plot.Watered || layerTileGID(ground.Tiles[idx]) != w.Farm.Soil {
+		t.Fatalf("after a watered night: stage %d, watered %v", plot.Stage, plot.Watered)
+	}
+	// A dry day doesn't
+	passMidnight(w)
+	if plot.Stage != 1 {
+		t.Fatalf("grew to stage %d without water", plot.Stage)
+	}
+	use("water again", "", w.Farm.WetSoil, tomato.Stages[1])
+	passMidnight(w)
+	if plot.Stage != 2 || layerTileGID(crops.Tiles[idx]) != tomato.Stages[2] {
+		t.Fatalf("stage %d, want ripe", plot.Stage)
+	}
+
+	use("harvest", "", w.Farm.Soil, 0)
+	if n := w.CountItem("tomato"); n != tomato.Count || w.Stats.ItemsGathered != tomato.Count {
+		t.Errorf("%d tomatoes, %d gathered, want %d", n, w.Stats.ItemsGathered, tomato.Count)
+	}
+	if plot.Crop != "" {
+		t.Errorf("plot still growing %q", plot.Crop)
+	}
+}
@@ -0,0 +1,77 @@
+package sim
+
+// burning reports whether a structure is a fire with fuel left.
+func (w *World) burning(s *Structure) bool {
+	b := w.buildable(s.Buildable)
+	return b != nil && b.Fuel > 0 && s.Fuel > 0
+}
+
+// stepFires runs once per in-game minute and burns one minute of fuel from
+// every lit fire on every map. A fire that runs out goes dark.
+func (w *World) stepFires() {
+	for _, s := range w.structures {
+		if !w.burning(s) {
+			continue
+		}
+		s.Fuel--
+		if s.Fuel == 0 {
+			w.douse(s)
+		}
+	}
+}
+
+// douse puts out a fire, whatever fuel it had left.
+func (w *World) douse(s *Structure) {
+	s.Fuel = 0
+	w.setTileOn(s.Map, structuresLayer, s.Index, w.buildable(s.Buildable).Unlit)
+}
+
+// refuel burns count of item in the fire the interaction started from,
+// lighting it again if it had gone out.
+func (w *World) refuel(item string, count int) {
+	if w.target == nil || !w.HasItem(item, count) {
+		return
+	}
+	s := w.structures[tileRef{w.MapName, w.target.index}]
+	if s == nil {
+		return
+	}
+	b := w.buildable(s.Buildable)
+	if b == nil || b.Fuel == 0 {
+		return
+	}
+	if w.raining() && w.rainsOn(s) {
+		w.notify("It's too wet to keep a fire going.")
+		return
+	}
+	if s.Fuel >= b.MaxFuel {
+		w.notify("The " + b.Name + " is burning as high as it can.")
+		return
+	}
+	w.RemoveItem(item, count)
+	if s.Fuel == 0 {
+		w.setTileOn(s.Map, structuresLayer, s.Index, b.Tile)
+	}
+	s.Fuel = min(s.Fuel+count*b.Fuel, b.MaxFuel)
+}
+
+// nearStation reports whether the player can use the given crafting station:
+// a burning structure providing it on one of the eight tiles around the
+// player, or the player's own tile.
+func (w *World) nearStation(station string) bool {
+	if station == "" {
+		return true
+	}
+	p := tileAt(w.Player.Pos)
+	for _, s := range w.structures {
+		b := w.buildable(s.Buildable)
+		if s.Map != w.MapName || b == nil || b.Station != station || (b.Fuel > 0 && s.Fuel == 0) {
+			continue
+		}
+		x, y := s.Index%w.Map.Width, s.Index/w.Map.Width
+		if abs(x-p.X) <= 1 && abs(y-p.Y) <= 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package sim
+
+import "testing"
+
+// buildCampfire places a campfire on the tile in front of the player.
+func buildCampfire(t *testing.T, w *World) *Structure {
+	t.Helper()
+	w.AddToInventory("wood", 3)
+	press(w, Input{Build: true})
+	if b := w.SelectedBuildable(); b == nil || b.ID != "campfire" {
+		t.Fatalf("build mode on %v, want the campfire", b)
+	}
+	target, ok := w.BuildTarget()
+	if !ok {
+		t.Fatalf("can't build at %v", target)
+	}
+	press(w, Input{Action: true})
+	s := w.structures[tileRef{w.MapName, target.Y*w.Map.Width + target.X}]
+	if s == nil {
+		t.Fatal("no campfire built")
+	}
+	if s.Fuel != w.buildable("campfire").Fuel {
+		t.Fatalf("built with %d fuel", s.Fuel)
+	}
+	press(w, Input{Back: true})
+	return s
+}
+
+func TestCampfire(t *testing.T) {
+	w := newTestWorld(t)
+	w.AddToInventory("wood", 2)
+	s := buildCampfire(t, w)
+	b := w.buildable("campfire")
+	tile := func() uint32 { return layerTileGID(w.layer(structuresLayer).Tiles[s.Index]) }
+
+	if tile() != b.Tile || w.CountItem("wood") != 2 {
+		t.Fatalf("built with tile %d and %d wood left", tile(), w.CountItem("wood"))
+	}
+	if !w.nearStation(b.Station) {
+		t.Error("a lit campfire next to the player is no station")
+	}
+
+	// Burns a minute of fuel every minute, then goes out
+	idle(w, (s.Fuel-1)*ticksPerGameMinute)
+	if s.Fuel != 1 || tile() != b.Tile {
+		t.Fatalf("fuel %d, tile %d a minute before it goes out", s.Fuel, tile())
+	}
+	idle(w, ticksPerGameMinute)
+	if s.Fuel != 0 || tile() != b.Unlit || w.nearStation(b.Station) {
+		t.Fatalf("burnt out: fuel %d, tile %d, station %v", s.Fuel, tile(), w.nearStation(b.Station))
+	}
+	idle(w, 5*ticksPerGameMinute)
+	if s.Fuel != 0 {
+		t.Fatalf("fuel %d on a dead fire", s.Fuel)
+	}
+
+	// Add wood through the campfire's dialogue
+	idle(w, actionBlockTicks)
+	w.Step(Input{Action: true})
+	if !w.Chatting || w.ConvNode != w.Conversations["campfire"] {
+		t.Fatal("facing the campfire doesn't open its dialogue")
+	}
+	press(w, Input{Action: true})
+	if s.Fuel != b.Fuel || tile() != b.Tile || w.CountItem("wood") != 1 {
+		t.Fatalf("relit with %d fuel, tile %d and %d wood left", s.Fuel, tile(), w.CountItem("wood"))
+	}
+
+	// Capped at maxFuel, and no wood is wasted on a full fire
+	w.target = &interactTarget{layer: structuresLayer, index: s.Index}
+	s.Fuel = b.MaxFuel - 10
+	w.refuel("wood", 1)
+	if s.Fuel != b.MaxFuel || w.CountItem("wood") != 0 {
+		t.Fatalf("fuel %d with %d wood left, want %d and 0", s.Fuel, w.CountItem("wood"), b.MaxFuel)
+	}
+	w.AddToInventory("wood", 1)
+	w.refuel("wood", 1)
+	if w.CountItem("wood") != 1 || w.TakeNotice() != "The Campfire is burning as high as it can." {
+		t.Error("wood burnt on a full fire")
+	}
+}
@@ -0,0 +1,155 @@
+package sim
+
+import "fmt"
+
+// FishPhase is how far a fishing attempt has got.
+type FishPhase int
+
+const (
+	FishWaiting FishPhase = iota // line in the water, wait for a bite
+	FishBite                     // something bites: press Action quickly
+	FishReeling                  // stop the marker inside the zone
+	FishDone                     // show how it went
+)
+
+const (
+	fishMinWait   = 2 * TicksPerSecond
+	fishMaxWait   = 6 * TicksPerSecond
+	fishBiteTicks = TicksPerSecond     // time to strike once something bites
+	fishReelTicks = 5 * TicksPerSecond // the fish escapes if not landed by then
+	fishDoneTicks = 2 * TicksPerSecond
+)
+
+// Fishing is an attempt in progress, started by the "fish" effect. The
+// renderer draws the phase over the map; while reeling, Marker sweeps back
+// and forth over a bar from 0 to 1 and the catch is landed by pressing
+// Action while it is between ZoneLo and ZoneHi.
+type Fishing struct {
+	Phase   FishPhase
+	Marker  float64
+	ZoneLo  float64
+	ZoneHi  float64
+	Message string // result shown in FishDone
+
+	table     *LootTable
+	catch     *LootEntry
+	since     int     // tick the phase started
+	biteAfter int     // ticks to wait for a bite
+	speed     float64 // marker movement per tick, negative going left
+	held      bool    // Action was down last tick, only presses count
+}
+
+// startFishing casts a line into the water tile the interaction started
+// from. What can be caught comes from the tile's loot_table property.
+func (w *World) startFishing() {
+	if w.target == nil {
+		return
+	}
+	var table *LootTable
+	if layer := w.layer(w.target.layer); layer != nil {
+		if id, ok := tileProp(layer, w.target.index, "loot_table"); ok {
+			table = w.LootTables[id]
+		}
+	}
+	f := &Fishing{table: table, since: w.Tick, held: true}
+	f.biteAfter = fishMinWait + w.rng.Intn(fishMaxWait-fishMinWait)
+	w.Fishing = f
+}
+
+// stepFishing advances a fishing attempt. Back gives up at any time.
+func (w *World) stepFishing(in Input) {
+	f := w.Fishing
+	pressed := in.Action && !f.held
+	f.held = in.Action
+	if in.Back {
+		w.endFishing()
+		return
+	}
+	elapsed := w.Tick - f.since
+	switch f.Phase {
+	case FishWaiting:
+		switch {
+		case pressed:
+			w.fishDone("You reel in too early.")
+		case elapsed >= f.biteAfter:
+			if f.table == nil {
+				w.fishDone("Nothing bites here.")
+				return
+			}
+			f.catch = w.roll(f.table)
+			if f.catch == nil {
+				w.fishDone("Nothing bites.")
+				return
+			}
+			w.fishPhase(FishBite)
+		}
+	case FishBite:
+		switch {
+		case pressed:
+			w.startReeling()
+		case elapsed >= fishBiteTicks:
+			w.fishDone("It got away.")
+		}
+	case FishReeling:
+		f.Marker += f.speed
+		if f.Marker < 0 || f.Marker > 1 {
+			f.speed = -f.speed
+			f.Marker = min(max(f.Marker, 0), 1)
+		}
+		switch {
+		case pressed && f.Marker >= f.ZoneLo && f.Marker <= f.ZoneHi:
+			w.landCatch()
+		case pressed:
+			w.fishDone("The line snaps. It got away.")
+		case elapsed >= fishReelTicks:
+			w.fishDone("It got away.")
+		}
+	case FishDone:
+		if pressed || elapsed >= fishDoneTicks {
+			w.endFishing()
+		}
+	}
+}
+
+// startReeling sets up the timing bar; harder catches get a narrower zone
+// and a faster marker.
+func (w *World) startReeling() {
+	f := w.Fishing
+	d := min(max(f.catch.Difficulty, 0), 1)
+	width := 0.35 - 0.25*d
+	f.ZoneLo = w.rng.Float64() * (1 - width)
+	f.ZoneHi = f.ZoneLo + width
+	f.Marker = 0
+	f.speed = (0.6 + 1.2*d) / TicksPerSecond
+	w.fishPhase(FishReeling)
+}
+
+func (w *World) landCatch() {
+	f := w.Fishing
+	name := w.ItemName(f.catch.Item)
+	left := w.AddToInventory(f.catch.Item, f.catch.Count)
+	w.Stats.ItemsGathered += f.catch.Count - left
+	switch {
+	case left == f.catch.Count:
+		w.fishDone(fmt.Sprintf("You catch %s, but have no room for it.", name))
+	case f.catch.Count > 1:
+		w.fishDone(fmt.Sprintf("You catch %d x %s!", f.catch.Count, name))
+	default:
+		w.fishDone(fmt.Sprintf("You catch %s!", name))
+	}
+}
+
+func (w *World) fishPhase(p FishPhase) {
+	w.Fishing.Phase = p
+	w.Fishing.since = w.Tick
+}
+
+func (w *World) fishDone(msg string) {
+	w.Fishing.Message = msg
+	w.fishPhase(FishDone)
+}
+
+func (w *World) endFishing() {
+	w.Fishing = nil
+	w.lastChatEnd = w.Tick // the key that ended it shouldn't open anything
+}
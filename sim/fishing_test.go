@@ -0,0 +1,146 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// castLine starts fishing from the first water tile of the current map.
+func castLine(t *testing.T, w *World) {
+	t.Helper()
+	water := w.layer("Water")
+	for idx := range water.Tiles {
+		if hasTile(water, idx) {
+			w.target = &interactTarget{layer: water.Name, index: idx}
+			w.startFishing()
+			return
+		}
+	}
+	t.Fatalf("no water on %s", w.MapName)
+}
+
+// waitFor steps with no keys held until the fishing attempt reaches phase.
+func waitFor(t *testing.T, w *World, phase FishPhase) {
+	t.Helper()
+	for i := 0; i < fishMaxWait+fishReelTicks; i++ {
+		if w.Fishing == nil || w.Fishing.Phase == phase {
+			break
+		}
+		w.Step(Input{})
+	}
+	if w.Fishing == nil || w.Fishing.Phase != phase {
+		t.Fatalf("fishing never reached phase %d", phase)
+	}
+}
+
+// strike lets go of Action for a tick and presses it.
+func strike(w *World) {
+	w.Step(Input{})
+	w.Step(Input{Action: true})
+}
+
+func TestFishing(t *testing.T) {
+	trout := &LootTable{ID: "test", Entries: []*LootEntry{{Item: "trout", Count: 1, Weight: 1, Difficulty: 0.4}}}
+	night := &LootTable{ID: "night", Entries: []*LootEntry{{Item: "catfish", Count: 1, Weight: 1, Conditions: []Condition{
+		{Type: "time", From: "20:00", To: "05:00"},
+	}}}}
+	if err := night.Entries[0].Conditions[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		table  *LootTable // nil fishes the map's own table, see castLine
+		full   bool
+		play   func(t *testing.T, w *World)
+		msg    string
+		caught int
+	}{
+		{"caught", trout, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			strike(w)
+			if f := w.Fishing; f.Phase != FishReeling || math.Abs(f.ZoneHi-f.ZoneLo-0.25) > 1e-9 {
+				t.Fatalf("phase %d, zone %v wide, want reeling a 0.25 zone", f.Phase, f.ZoneHi-f.ZoneLo)
+			}
+			w.Fishing.ZoneLo, w.Fishing.ZoneHi = 0, 1
+			strike(w)
+		}, "You catch Trout!", 1},
+		{"no room", trout, true, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			strike(w)
+			w.Fishing.ZoneLo, w.Fishing.ZoneHi = 0, 1
+			strike(w)
+		}, "You catch Trout, but have no room for it.", 0},
+		{"too early", trout, false, func(t *testing.T, w *World) {
+			strike(w)
+		}, "You reel in too early.", 0},
+		{"slow strike", trout, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			idle(w, fishBiteTicks)
+		}, "It got away.", 0},
+		{"line snaps", trout, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			strike(w)
+			w.Fishing.ZoneLo, w.Fishing.ZoneHi = 0.5, 0.6
+			strike(w)
+		}, "The line snaps. It got away.", 0},
+		{"reeled too long", trout, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			strike(w)
+			idle(w, fishReelTicks)
+		}, "It got away.", 0},
+		{"out of hours", night, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishDone)
+		}, "Nothing bites.", 0},
+		{"river", nil, false, func(t *testing.T, w *World) {
+			waitFor(t, w, FishBite)
+			if w.Fishing.catch == nil {
+				t.Fatal("bite with no catch")
+			}
+			w.Fishing.catch = trout.Entries[0]
+			strike(w)
+			w.Fishing.ZoneLo, w.Fishing.ZoneHi = 0, 1
+			strike(w)
+		}, "You catch Trout!", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			if tt.full {
+				w.AddToInventory("hoe", 64)
+			}
+			castLine(t, w)
+			if w.Fishing.table == nil {
+				t.Fatal("the map's water has no loot table")
+			}
+			if tt.table != nil {
+				w.Fishing.table = tt.table
+			}
+			tt.play(t, w)
+			waitFor(t, w, FishDone)
+			if w.Fishing.Message != tt.msg {
+				t.Errorf("message %q, want %q", w.Fishing.Message, tt.msg)
+			}
+			if n := w.CountItem("trout"); n != tt.caught || w.Stats.ItemsGathered != tt.caught {
+				t.Errorf("%d trout, %d gathered, want %d", n, w.Stats.ItemsGathered, tt.caught)
+			}
+			idle(w, fishDoneTicks)
+			if w.Fishing != nil {
+				t.Error("result never cleared")
+			}
+		})
+	}
+}
+
+func TestFishingGiveUp(t *testing.T) {
+	w := newTestWorld(t)
+	castLine(t, w)
+	w.Step(Input{Back: true})
+	if w.Fishing != nil {
+		t.Fatal("Back didn't stop fishing")
+	}
+	// Not straight into the inventory either
+	w.Step(Input{Action: true})
+	if w.InventoryOpen {
+		t.Error("inventory opened with the key that stopped fishing")
+	}
+}
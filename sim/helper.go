@@ -0,0 +1,51 @@
+package sim
+
+import "image"
+
+func isFacingNPC(w *World, npc *NPC) bool {
+	if npc.Away {
+		return false
+	}
+	// Player must be within a 2x2 tile area around the NPC (more generous)
+	p := w.Player.Pos
+	playerRect := image.Rect(p.X, p.Y, p.X+TileSize, p.Y+TileSize)
+	npcRect := image.Rect(npc.Pos.X-TileSize/2, npc.Pos.Y-TileSize/2, npc.Pos.X+TileSize*3/2, npc.Pos.Y+TileSize*3/2)
+	return playerRect.Overlaps(npcRect)
+}
+
+// dialogue is the name of the conversation the NPC starts.
+func (npc *NPC) dialogue() string {
+	if npc.def != nil && npc.def.Dialogue != "" {
+		return npc.def.Dialogue
+	}
+	return npc.Name
+}
+
+func sign(x int) int {
+	if x < 0 {
+		return -1
+	}
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// notify leaves a short message for the player, e.g. why an action failed.
+func (w *World) notify(msg string) {
+	w.notice = msg
+}
+
+// TakeNotice returns the latest message for the player once, or "".
+func (w *World) TakeNotice() string {
+	msg := w.notice
+	w.notice = ""
+	return msg
+}
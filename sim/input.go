@@ -0,0 +1,15 @@
+package sim
+
+// Input is a snapshot of the controls held down during one tick. The renderer
+// fills it from the keyboard; tests and headless runs can build it directly.
+type Input struct {
+	Left, Right, Up, Down bool
+	Action                bool // interact, confirm a choice, open/close inventory, place a structure
+	Back                  bool // leave the trade window or build mode, stop fishing
+	Craft                 bool // craft the selected recipe in the inventory
+	Eat                   bool
+	Use                   bool // farm the tile in front: till, plant, water or harvest
+	Build                 bool // open/close build mode
+	Next                  bool // select the next buildable in build mode
+	Restart               bool // any restart key after game over
+}
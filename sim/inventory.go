@@ -0,0 +1,126 @@
+package sim
+
+// AddToInventory stacks count of item into the inventory, up to the item's
+// max stack per cell. It returns how many did not fit; items missing from the
+// registry are rejected entirely.
+func (w *World) AddToInventory(item string, count int) int {
+	def := w.Items[item]
+	if def == nil || count <= 0 {
+		return count
+	}
+	maxPerCell := def.MaxStack
+	// Try to stack first, up to maxPerCell
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			slot := &w.Inventory[y][x]
+			if slot.Item == item && slot.Count > 0 && slot.Count < maxPerCell {
+				add := count
+				if slot.Count+add > maxPerCell {
+					add = maxPerCell - slot.Count
+				}
+				slot.Count += add
+				count -= add
+				if count <= 0 {
+					return 0
+				}
+			}
+		}
+	}
+	// Find first empty slot(s)
+	for y := 0; y < 8 && count > 0; y++ {
+		for x := 0; x < 8 && count > 0; x++ {
+			slot := &w.Inventory[y][x]
+			if slot.Item == "" || slot.Count == 0 {
+				add := count
+				if add > maxPerCell {
+					add = maxPerCell
+				}
+				slot.Item = item
+				slot.Count = add
+				count -= add
+			}
+		}
+	}
+	return count
+}
+
+// CanAdd reports whether count of item would fit in the inventory.
+func (w *World) CanAdd(item string, count int) bool {
+	def := w.Items[item]
+	if def == nil {
+		return false
+	}
+	room := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			slot := &w.Inventory[y][x]
+			switch {
+			case slot.Item == "" || slot.Count == 0:
+				room += def.MaxStack
+			case slot.Item == item:
+				room += max(def.MaxStack-slot.Count, 0)
+			}
+		}
+	}
+	return room >= count
+}
+
+func (w *World) HasItem(item string, count int) bool {
+	return w.CountItem(item) >= count
+}
+
+// CountItem totals an item over every inventory slot.
+func (w *World) CountItem(item string) int {
+	total := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			slot := &w.Inventory[y][x]
+			if slot.Item == item {
+				total += slot.Count
+			}
+		}
+	}
+	return total
+}
+
+func (w *World) RemoveItem(item string, count int) {
+	for y := 0; y < 8 && count > 0; y++ {
+		for x := 0; x < 8 && count > 0; x++ {
+			slot := &w.Inventory[y][x]
+			if slot.Item == item && slot.Count > 0 {
+				if slot.Count > count {
+					slot.Count -= count
+					return
+				} else {
+					count -= slot.Count
+					slot.Count = 0
+					slot.Item = ""
+				}
+			}
+		}
+	}
+}
+
+// FirstFood returns the first edible item in the inventory, or "".
+func (w *World) FirstFood() string {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			slot := &w.Inventory[y][x]
+			if def := w.Items[slot.Item]; def != nil && slot.Count > 0 && def.Food > 0 {
+				return slot.Item
+			}
+		}
+	}
+	return ""
+}
+
+// Eat consumes one of item and restores hunger by its food value.
+func (w *World) Eat(item string) bool {
+	def := w.Items[item]
+	if def == nil || def.Food <= 0 || !w.HasItem(item, 1) {
+		return false
+	}
+	w.RemoveItem(item, 1)
+	w.Hunger = min(w.Hunger+def.Food, 1.0)
+	return true
+}
@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ItemDef describes one kind of item. Icon is the local tile ID of the item's
+// picture in the roguelike tileset, -1 for none. Food is how much of the
+// hunger bar eating one restores; 0 means it is not edible.
+type ItemDef struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Icon        int       `json:"icon"`
+	MaxStack    int       `json:"maxStack"`
+	Category    string    `json:"category"`
+	Food        float64   `json:"food,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Light       *LightDef `json:"light,omitempty"` // given off while carried
+}
+
+// LoadItems reads the item registry from a JSON file, keyed by item ID.
+func LoadItems(path string) (map[string]*ItemDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Items []*ItemDef `json:"items"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	items := map[string]*ItemDef{}
+	for _, it := range f.Items {
+		switch {
+		case it.ID == "":
+			return nil, fmt.Errorf("%s: item %q has no id", path, it.Name)
+		case items[it.ID] != nil:
+			return nil, fmt.Errorf("%s: duplicate item id %q", path, it.ID)
+		case it.MaxStack <= 0:
+			return nil, fmt.Errorf("%s: item %q needs a positive maxStack", path, it.ID)
+		}
+		if it.Name == "" {
+			it.Name = it.ID
+		}
+		if it.Light != nil {
+			if err := it.Light.compile(); err != nil {
+				return nil, fmt.Errorf("%s: item %q light: %w", path, it.ID, err)
+			}
+		}
+		items[it.ID] = it
+	}
+	return items, nil
+}
+
+// ItemName returns the display name of an item ID.
+func (w *World) ItemName(id string) string {
+	if def := w.Items[id]; def != nil {
+		return def.Name
+	}
+	return id
+}
+
+// itemByName finds an item by display name, used to migrate old saves that
+// stored names instead of IDs.
+func (w *World) itemByName(name string) *ItemDef {
+	for _, def := range w.Items {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// checkItems reports references to items missing from the registry.
+func (w *World) checkItems(where string, ids ...string) error {
+	for _, id := range ids {
+		if w.Items[id] == nil {
+			return fmt.Errorf("%s: unknown item %q", where, id)
+		}
+	}
+	return nil
+}
+
+// validateItems checks that every recipe, shop and conversation only uses
+// registered items.
+func (w *World) validateItems() error {
+	for name, root := range w.Conversations {
+		seen := map[*ConversationNode]bool{}
+		queue := []*ConversationNode{root}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			if seen[node] {
+				continue
+			}
+			seen[node] = true
+			for _, c := range node.Choices {
+				for _, cond := range c.Conditions {
+					if cond.Type == "hasItem" {
+						if err := w.checkItems("dialogue "+name, cond.Item); err != nil {
+							return err
+						}
+					}
+				}
+				for _, e := range c.Effects {
+					if e.Type == "addItem" || e.Type == "removeItem" || e.Type == "refuel" {
+						if err := w.checkItems("dialogue "+name, e.Item); err != nil {
+							return err
+						}
+					}
+				}
+				if c.Next != nil {
+					queue = append(queue, c.Next)
+				}
+			}
+		}
+	}
+	for _, r := range w.Recipes {
+		for _, ic := range append(append([]ItemCount{}, r.Inputs...), r.Outputs...) {
+			if err := w.checkItems("recipe "+r.ID, ic.Item); err != nil {
+				return err
+			}
+		}
+	}
+	for name, shop := range w.Shops {
+		for _, it := range shop.Def.Stock {
+			if err := w.checkItems("shop "+name, it.Item); err != nil {
+				return err
+			}
+		}
+	}
+	return w.checkItems("currency", Currency)
+}
@@ -0,0 +1,163 @@
+package sim
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// Any tile can give off light at night through these properties, set on the
+// tile in the tileset or on its whole layer like the other tile properties:
+//
+//	light          float  radius in tiles
+//	light_color    color  defaults to warm white
+//	light_flicker  float  0 for steady, up to 1 for a wildly flickering flame
+
+// defaultLightColor is used when a light sets no colour.
+var defaultLightColor = color.RGBA{255, 220, 170, 255}
+
+// Light is a point of light on the current map, in map pixels. The renderer
+// flickers its size and brightness by up to Flicker.
+type Light struct {
+	X, Y    int
+	Radius  float64
+	Color   color.RGBA
+	Flicker float64
+}
+
+// LightDef is the light an item gives off while the player carries it, e.g.
+// a lantern. Color is "#RRGGBB".
+type LightDef struct {
+	Radius  float64 `json:"radius"`
+	Color   string  `json:"color,omitempty"`
+	Flicker float64 `json:"flicker,omitempty"`
+
+	color color.RGBA
+}
+
+func (d *LightDef) compile() error {
+	d.color = defaultLightColor
+	if d.Color == "" {
+		return nil
+	}
+	c, err := parseLightColor(d.Color)
+	if err != nil {
+		return err
+	}
+	d.color = c
+	return nil
+}
+
+func parseLightColor(s string) (color.RGBA, error) {
+	c, err := tiled.ParseHexColor(s)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return color.RGBAModel.Convert(&c).(color.RGBA), nil
+}
+
+type lightKey struct {
+	layer *tiled.Layer
+	index int
+}
+
+// Lights lists the lights on the current map: lit tiles and whatever the
+// player carries.
+func (w *World) Lights() []Light {
+	m := w.Map
+	tiles := w.tileLights(m)
+	lights := make([]Light, 0, len(tiles)+1)
+	for _, l := range tiles {
+		lights = append(lights, l)
+	}
+	if d := w.carriedLight(); d != nil {
+		lights = append(lights, Light{
+			X:       w.Player.Pos.X + TileSize/2,
+			Y:       w.Player.Pos.Y + TileSize/2,
+			Radius:  d.Radius * TileSize,
+			Color:   d.color,
+			Flicker: d.Flicker,
+		})
+	}
+	return lights
+}
+
+// carriedLight is the brightest light among the items in the inventory.
+func (w *World) carriedLight() *LightDef {
+	var best *LightDef
+	for y := range w.Inventory {
+		for x := range w.Inventory[y] {
+			slot := w.Inventory[y][x]
+			def := w.Items[slot.Item]
+			if slot.Count == 0 || def == nil || def.Light == nil {
+				continue
+			}
+			if best == nil || def.Light.Radius > best.Radius {
+				best = def.Light
+			}
+		}
+	}
+	return best
+}
+
+// tileLights returns the lit tiles of a map, scanning it the first time.
+// tileChanged keeps the result up to date afterwards.
+func (w *World) tileLights(m *tiled.Map) map[lightKey]Light {
+	if lights := w.lights[m]; lights != nil {
+		return lights
+	}
+	lights := map[lightKey]Light{}
+	for _, layer := range m.Layers {
+		for idx := range layer.Tiles {
+			if l, ok := tileLight(m, layer, idx); ok {
+				lights[lightKey{layer, idx}] = l
+			}
+		}
+	}
+	if w.lights == nil {
+		w.lights = map[*tiled.Map]map[lightKey]Light{}
+	}
+	w.lights[m] = lights
+	return lights
+}
+
+// updateLight refreshes a changed tile of a map whose lights are known.
+func (w *World) updateLight(m *tiled.Map, layer *tiled.Layer, idx int) {
+	lights := w.lights[m]
+	if lights == nil {
+		return
+	}
+	if l, ok := tileLight(m, layer, idx); ok {
+		lights[lightKey{layer, idx}] = l
+	} else {
+		delete(lights, lightKey{layer, idx})
+	}
+}
+
+// tileLight reads the light properties of a tile.
+func tileLight(m *tiled.Map, layer *tiled.Layer, idx int) (Light, bool) {
+	if !layer.Visible || !hasTile(layer, idx) {
+		return Light{}, false
+	}
+	v, _ := tileProp(layer, idx, "light")
+	radius, err := strconv.ParseFloat(v, 64)
+	if err != nil || radius <= 0 {
+		return Light{}, false
+	}
+	l := Light{
+		X:      idx%m.Width*TileSize + TileSize/2,
+		Y:      idx/m.Width*TileSize + TileSize/2,
+		Radius: radius * TileSize,
+		Color:  defaultLightColor,
+	}
+	if v, ok := tileProp(layer, idx, "light_color"); ok {
+		if c, err := parseLightColor(v); err == nil {
+			l.Color = c
+		}
+	}
+	if v, ok := tileProp(layer, idx, "light_flicker"); ok {
+		l.Flicker, _ = strconv.ParseFloat(v, 64)
+	}
+	return l, true
+}
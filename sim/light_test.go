@@ -0,0 +1,68 @@
+package sim
+
+import (
+	"image/color"
+	"testing"
+)
+
+// lightAt finds the light centred on a tile of the current map.
+func lightAt(w *World, idx int) (Light, bool) {
+	x, y := idx%w.Map.Width*TileSize+TileSize/2, idx/w.Map.Width*TileSize+TileSize/2
+	for _, l := range w.Lights() {
+		if l.X == x && l.Y == y {
+			return l, true
+		}
+	}
+	return Light{}, false
+}
+
+func TestTileLights(t *testing.T) {
+	w := newTestWorld(t)
+	// Lights are listed at any hour, the renderer only lets them show
+	// once it gets dark
+	w.GameMinutes = 22 * 60
+
+	// Windows light up from their layer's properties
+	windows := w.layer("Windows")
+	lit := 0
+	for idx := range windows.Tiles {
+		if !hasTile(windows, idx) {
+			continue
+		}
+		want := Light{Radius: 1.5 * TileSize, Color: color.RGBA{0xff, 0xd8, 0x90, 0xff}}
+		l, ok := lightAt(w, idx)
+		if !ok || l.Radius != want.Radius || l.Color != want.Color || l.Flicker != 0 {
+			t.Fatalf("window %d: light %+v, want %+v", idx, l, want)
+		}
+		lit++
+	}
+	if lit == 0 {
+		t.Fatal("no windows on the start map")
+	}
+
+	// A campfire lights up from its tile's properties, and goes dark when
+	// it's taken away
+	fire := w.tileIndex(w.Player.Pos) + 2
+	if _, ok := lightAt(w, fire); ok {
+		t.Fatal("light before the fire is built")
+	}
+	if err := w.SetTile(structuresLayer, fire, w.buildable("campfire").Tile); err != nil {
+		t.Fatal(err)
+	}
+	want := Light{Radius: 4 * TileSize, Color: color.RGBA{0xff, 0xa0, 0x50, 0xff}, Flicker: 0.4}
+	if l, ok := lightAt(w, fire); !ok || l.Radius != want.Radius || l.Color != want.Color || l.Flicker != want.Flicker {
+		t.Errorf("campfire light %+v, want %+v", l, want)
+	}
+	if err := w.SetTile(structuresLayer, fire, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lightAt(w, fire); ok {
+		t.Error("light left behind by a removed campfire")
+	}
+
+	// A torch in the inventory lights the player
+	w.AddToInventory("torch", 1)
+	if l, ok := lightAt(w, w.tileIndex(w.Player.Pos)); !ok || l.Radius != 3*TileSize {
+		t.Errorf("carried torch light %+v, want radius %d", l, 3*TileSize)
+	}
+}
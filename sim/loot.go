@@ -0,0 +1,120 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LootEntry is one possible drop of a loot table. Entries whose conditions
+// don't hold right now (the same conditions dialogue choices use, e.g. a time
+// range for night fish) are left out of the roll. Difficulty, from 0 to 1,
+// makes the catch minigame harder.
+type LootEntry struct {
+	Item       string      `json:"item"`
+	Count      int         `json:"count,omitempty"`
+	Weight     int         `json:"weight"`
+	Difficulty float64     `json:"difficulty,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// LootTable is a weighted list of drops, picked by the loot_table property
+// of the tile being fished.
+type LootTable struct {
+	ID      string       `json:"id"`
+	Entries []*LootEntry `json:"entries"`
+}
+
+// LoadLootTables reads the loot tables from a JSON file, keyed by ID.
+func LoadLootTables(path string) (map[string]*LootTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Tables []*LootTable `json:"tables"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	tables := map[string]*LootTable{}
+	for _, t := range f.Tables {
+		switch {
+		case t.ID == "":
+			return nil, fmt.Errorf("%s: loot table has no id", path)
+		case tables[t.ID] != nil:
+			return nil, fmt.Errorf("%s: duplicate loot table %q", path, t.ID)
+		}
+		for _, e := range t.Entries {
+			if e.Weight <= 0 {
+				return nil, fmt.Errorf("%s: loot table %q: %q needs a positive weight", path, t.ID, e.Item)
+			}
+			if e.Count == 0 {
+				e.Count = 1
+			}
+			for i := range e.Conditions {
+				if err := e.Conditions[i].compile(); err != nil {
+					return nil, fmt.Errorf("%s: loot table %q: %w", path, t.ID, err)
+				}
+			}
+		}
+		tables[t.ID] = t
+	}
+	return tables, nil
+}
+
+// roll picks an entry of a table by weight among those whose conditions
+// hold, or nil if none do.
+func (w *World) roll(t *LootTable) *LootEntry {
+	var open []*LootEntry
+	total := 0
+	for _, e := range t.Entries {
+		ok := true
+		for _, c := range e.Conditions {
+			ok = ok && w.Check(c)
+		}
+		if ok {
+			open = append(open, e)
+			total += e.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	n := w.rng.Intn(total)
+	for _, e := range open {
+		if n < e.Weight {
+			return e
+		}
+		n -= e.Weight
+	}
+	return nil
+}
+
+// validateLoot checks that loot tables only drop registered items and that
+// every loot_table property of a loaded map, on a layer or on a tile of one
+// of its tilesets, names a table.
+func (w *World) validateLoot() error {
+	for id, t := range w.LootTables {
+		for _, e := range t.Entries {
+			if err := w.checkItems("loot table "+id, e.Item); err != nil {
+				return err
+			}
+		}
+	}
+	for name, m := range w.maps {
+		for _, layer := range m.Layers {
+			if id := layer.Properties.GetString("loot_table"); id != "" && w.LootTables[id] == nil {
+				return fmt.Errorf("map %q layer %q: unknown loot table %q", name, layer.Name, id)
+			}
+		}
+		for _, ts := range m.Tilesets {
+			for _, tt := range ts.Tiles {
+				if v := tt.Properties.Get("loot_table"); len(v) > 0 && w.LootTables[v[0]] == nil {
+					return fmt.Errorf("map %q tileset %q tile %d: unknown loot table %q", name, ts.Name, tt.ID, v[0])
+				}
+			}
+		}
+	}
+	return nil
+}
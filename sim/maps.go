@@ -0,0 +1,148 @@
+package sim
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// MapLoader loads a TMX map by name, e.g. "house_interior".
+type MapLoader func(name string) (*tiled.Map, error)
+
+// Portal leads from a spot on one map to a named spawn point on another. It
+// comes from a Tiled object with target_map and target_spawn properties, or
+// from a tile layer carrying those properties, in which case every tile on
+// the layer is a way through.
+type Portal struct {
+	TargetMap   string
+	TargetSpawn string
+}
+
+func portalFrom(props tiled.Properties) (Portal, bool) {
+	p := Portal{TargetMap: props.GetString("target_map"), TargetSpawn: props.GetString("target_spawn")}
+	return p, p.TargetMap != ""
+}
+
+// mapByName returns a loaded map, loading it on first use. Loaded maps are
+// kept for the whole run so their tile and NPC state survives leaving them.
+func (w *World) mapByName(name string) (*tiled.Map, error) {
+	if m := w.maps[name]; m != nil {
+		return m, nil
+	}
+	if w.LoadMap == nil {
+		return nil, fmt.Errorf("map %q is not loaded", name)
+	}
+	m, err := w.LoadMap(name)
+	if err != nil {
+		return nil, fmt.Errorf("map %q: %w", name, err)
+	}
+	w.maps[name] = m
+	return m, nil
+}
+
+// portalAt finds the portal covering a tile of the current map.
+func (w *World) portalAt(t TilePoint) (Portal, bool) {
+	centre := image.Point{X: t.X*TileSize + TileSize/2, Y: t.Y*TileSize + TileSize/2}
+	for _, group := range w.Map.ObjectGroups {
+		for _, obj := range group.Objects {
+			rect := image.Rect(int(obj.X), int(obj.Y), int(obj.X+obj.Width), int(obj.Y+obj.Height))
+			if p, ok := portalFrom(obj.Properties); ok && centre.In(rect) {
+				return p, true
+			}
+		}
+	}
+	if t.X < 0 || t.Y < 0 || t.X >= w.Map.Width || t.Y >= w.Map.Height {
+		return Portal{}, false
+	}
+	for _, layer := range w.Map.Layers {
+		if p, ok := portalFrom(layer.Properties); ok && hasTile(layer, t.Y*w.Map.Width+t.X) {
+			return p, true
+		}
+	}
+	return Portal{}, false
+}
+
+// spawnPoint finds the object named spawn on a map.
+func spawnPoint(m *tiled.Map, spawn string) (image.Point, bool) {
+	for _, group := range m.ObjectGroups {
+		for _, obj := range group.Objects {
+			if obj.Name == spawn {
+				return image.Point{X: int(obj.X) / TileSize * TileSize, Y: int(obj.Y) / TileSize * TileSize}, true
+			}
+		}
+	}
+	return image.Point{}, false
+}
+
+// EnterMap moves the player to the spawn point of another map. NPCs on the
+// map being left keep their places until the player comes back.
+func (w *World) EnterMap(name, spawn string) error {
+	m, err := w.mapByName(name)
+	if err != nil {
+		return err
+	}
+	pos, ok := spawnPoint(m, spawn)
+	if !ok {
+		return fmt.Errorf("map %q has no spawn point %q", name, spawn)
+	}
+	w.setMap(name, m)
+	w.inTriggers = nil
+	w.Player.Pos = pos
+	w.Player.Moving = false
+	return nil
+}
+
+func (w *World) setMap(name string, m *tiled.Map) {
+	w.MapName, w.Map = name, m
+	w.NPCs = w.npcsByMap[name]
+	w.invalidateGrid()
+}
+
+// allNPCs lists the NPCs of every map.
+func (w *World) allNPCs() []*NPC {
+	return w.everyNPC
+}
+
+// validatePortals follows every portal reachable from the start map and
+// checks that its target map loads and has the spawn point.
+func (w *World) validatePortals() error {
+	seen := map[string]bool{}
+	queue := []string{w.StartMap}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		m, err := w.mapByName(name)
+		if err != nil {
+			return err
+		}
+		var portals []Portal
+		for _, group := range m.ObjectGroups {
+			for _, obj := range group.Objects {
+				if p, ok := portalFrom(obj.Properties); ok {
+					portals = append(portals, p)
+				}
+			}
+		}
+		for _, layer := range m.Layers {
+			if p, ok := portalFrom(layer.Properties); ok {
+				portals = append(portals, p)
+			}
+		}
+		for _, p := range portals {
+			target, err := w.mapByName(p.TargetMap)
+			if err != nil {
+				return fmt.Errorf("portal on map %q: %w", name, err)
+			}
+			if _, ok := spawnPoint(target, p.TargetSpawn); !ok {
+				return fmt.Errorf("portal on map %q: map %q has no spawn point %q", name, p.TargetMap, p.TargetSpawn)
+			}
+			queue = append(queue, p.TargetMap)
+		}
+	}
+	return nil
+}
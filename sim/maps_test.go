@@ -0,0 +1,65 @@
+package sim
+
+import "testing"
+
+// goThrough stands the player on a tile of the current map facing down and
+// presses action.
+func goThrough(t *testing.T, w *World, from TilePoint) {
+	t.Helper()
+	idle(w, actionBlockTicks)
+	w.Player.Pos = from.pos()
+	w.Player.Dir = 0
+	if _, ok := w.portalAt(TilePoint{from.X, from.Y + 1}); !ok {
+		t.Fatalf("no portal below %v on %s", from, w.MapName)
+	}
+	w.Step(Input{Action: true})
+}
+
+func TestPortals(t *testing.T) {
+	w := newTestWorld(t)
+	outside := w.Map
+	npcs := w.NPCs
+	if err := w.SetTile("Resources", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The house door covers tiles 34-35 across, 28-29 down
+	goThrough(t, w, TilePoint{34, 27})
+	if w.MapName != "house_interior" {
+		t.Fatalf("on %s after the house door, want house_interior", w.MapName)
+	}
+	want, _ := spawnPoint(w.Map, "entrance")
+	if w.Player.Pos != want {
+		t.Errorf("inside at %v, want the entrance at %v", w.Player.Pos, want)
+	}
+	if len(w.NPCs) != len(w.npcsByMap["house_interior"]) {
+		t.Errorf("%d NPCs inside, want the house's %d", len(w.NPCs), len(w.npcsByMap["house_interior"]))
+	}
+
+	// Pressing action again straight away doesn't bounce back out
+	w.Player.Dir = 0
+	w.Step(Input{Action: true})
+	if w.MapName != "house_interior" {
+		t.Fatal("went straight back through the door")
+	}
+
+	goThrough(t, w, TilePoint{5, 8})
+	if w.MapName != "jons_first_map" || w.Map != outside {
+		t.Fatalf("on %s after the front door, want the same jons_first_map", w.MapName)
+	}
+	want, _ = spawnPoint(w.Map, "front_door")
+	if w.Player.Pos != want {
+		t.Errorf("outside at %v, want the front door at %v", w.Player.Pos, want)
+	}
+	if layerTileGID(w.layer("Resources").Tiles[0]) != 1 {
+		t.Error("the tile changed before going in was lost")
+	}
+	if len(w.NPCs) != len(npcs) {
+		t.Fatalf("%d NPCs outside, had %d", len(w.NPCs), len(npcs))
+	}
+	for i := range npcs {
+		if w.NPCs[i] != npcs[i] {
+			t.Errorf("NPC %s was replaced", npcs[i].Name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package sim
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// Level designers populate maps with Tiled objects, told apart by their class
+// (or type, in maps saved before Tiled 1.9):
+//
+//	player_spawn  point where every run starts (start map only)
+//	npc           NPC spawn; properties name, sprite and dialogue
+//	trigger       rectangle; properties on_enter and on_exit name an
+//	              interaction dialogue to start, once=true fires only once
+//	item          pickup lying on the map; properties item and count
+
+// Pickup is an item lying on the current map, collected by walking over it.
+type Pickup struct {
+	Item  string
+	Count int
+	Pos   image.Point
+	key   string
+}
+
+// objectClass is the class of a Tiled object, falling back to its type.
+func objectClass(obj *tiled.Object) string {
+	if obj.Class != "" {
+		return obj.Class
+	}
+	return obj.Type
+}
+
+// objectKey identifies an object across saves.
+func objectKey(mapName string, obj *tiled.Object) string {
+	return fmt.Sprintf("%s:%d", mapName, obj.ID)
+}
+
+func objectRect(obj *tiled.Object) image.Rectangle {
+	return image.Rect(int(obj.X), int(obj.Y), int(obj.X+obj.Width), int(obj.Y+obj.Height))
+}
+
+// objectTile snaps an object's position to the tile grid.
+func objectTile(obj *tiled.Object) TilePoint {
+	return TilePoint{int(obj.X) / TileSize, int(obj.Y) / TileSize}
+}
+
+// mapObjects lists the objects of a class on a map.
+func mapObjects(m *tiled.Map, class string) []*tiled.Object {
+	var objs []*tiled.Object
+	for _, group := range m.ObjectGroups {
+		for _, obj := range group.Objects {
+			if objectClass(obj) == class {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs
+}
+
+// playerSpawn is where a run starts: the start map's player_spawn object, or
+// the map centre if it has none.
+func (w *World) playerSpawn() image.Point {
+	if spawns := mapObjects(w.maps[w.StartMap], "player_spawn"); len(spawns) > 0 {
+		return objectTile(spawns[0]).pos()
+	}
+	return w.mapCenter()
+}
+
+// npcSpawns merges NPCDefs with the npc objects of every loaded map. An
+// object naming a defined NPC places it; any other object adds an NPC that
+// just wanders.
+func (w *World) npcSpawns() []*NPCDef {
+	defs := make([]*NPCDef, len(w.NPCDefs))
+	byName := map[string]int{}
+	for i, def := range w.NPCDefs {
+		placed := *def
+		defs[i] = &placed
+		byName[def.Name] = i
+	}
+	names := make([]string, 0, len(w.maps))
+	for name := range w.maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, mapName := range names {
+		for _, obj := range mapObjects(w.maps[mapName], "npc") {
+			name := obj.Properties.GetString("name")
+			if name == "" {
+				name = obj.Name
+			}
+			def := &NPCDef{Name: name}
+			if i, ok := byName[name]; ok {
+				def = defs[i]
+			} else {
+				byName[name] = len(defs)
+				defs = append(defs, def)
+			}
+			def.onMap = mapName
+			def.start = objectTile(obj)
+			def.placed = true
+			if sprite := obj.Properties.GetString("sprite"); sprite != "" {
+				def.Sprite = sprite
+			}
+			if dialogue := obj.Properties.GetString("dialogue"); dialogue != "" {
+				def.Dialogue = dialogue
+			}
+		}
+	}
+	return defs
+}
+
+// Pickups lists the items still lying on the current map.
+func (w *World) Pickups() []Pickup {
+	var pickups []Pickup
+	for _, obj := range mapObjects(w.Map, "item") {
+		key := objectKey(w.MapName, obj)
+		if w.pickedUp[key] {
+			continue
+		}
+		count := obj.Properties.GetInt("count")
+		if count <= 0 {
+			count = 1
+		}
+		pickups = append(pickups, Pickup{
+			Item:  obj.Properties.GetString("item"),
+			Count: count,
+			Pos:   objectTile(obj).pos(),
+			key:   key,
+		})
+	}
+	return pickups
+}
+
+// PickedUp lists the keys of every pickup collected this run, for saving.
+func (w *World) PickedUp() []string {
+	keys := make([]string, 0, len(w.pickedUp))
+	for key := range w.pickedUp {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectPickups picks up every item the player is standing on.
+func (w *World) collectPickups() {
+	p := w.Player.Pos
+	playerRect := image.Rect(p.X, p.Y, p.X+TileSize, p.Y+TileSize)
+	for _, it := range w.Pickups() {
+		itemRect := image.Rect(it.Pos.X, it.Pos.Y, it.Pos.X+TileSize, it.Pos.Y+TileSize)
+		if !playerRect.Overlaps(itemRect) || !w.CanAdd(it.Item, it.Count) {
+			continue
+		}
+		w.AddToInventory(it.Item, it.Count)
+		w.Stats.ItemsGathered += it.Count
+		w.pickedUp[it.key] = true
+	}
+}
+
+// stepTriggers starts the on_enter and on_exit dialogues of trigger zones
+// the player walked into or out of this tick. After a reset, load or map
+// change it only notes where the player stands, so zones fire on walking in.
+func (w *World) stepTriggers() {
+	silent := w.inTriggers == nil
+	p := w.Player.Pos
+	centre := image.Point{X: p.X + TileSize/2, Y: p.Y + TileSize/2}
+	inside := map[string]bool{}
+	for _, obj := range mapObjects(w.Map, "trigger") {
+		key := objectKey(w.MapName, obj)
+		if !centre.In(objectRect(obj)) {
+			if w.inTriggers[key] && !silent {
+				w.fireTrigger(obj, key, "on_exit")
+			}
+			continue
+		}
+		inside[key] = true
+		if !w.inTriggers[key] && !silent {
+			w.fireTrigger(obj, key, "on_enter")
+		}
+	}
+	w.inTriggers = inside
+}
+
+func (w *World) fireTrigger(obj *tiled.Object, key, event string) {
+	conv := w.Conversations[obj.Properties.GetString(event)]
+	if conv == nil || w.Chatting {
+		return
+	}
+	flag := "triggered:" + key + ":" + event
+	if obj.Properties.GetBool("once") {
+		if w.Flags[flag] {
+			return
+		}
+		if w.Flags == nil {
+			w.Flags = map[string]bool{}
+		}
+		w.Flags[flag] = true
+	}
+	w.startAction(conv, nil)
+}
+
+// validateObjects checks the objects of every loaded map for pickups of
+// unknown items and triggers naming missing dialogues.
+func (w *World) validateObjects() error {
+	for name, m := range w.maps {
+		for _, obj := range mapObjects(m, "item") {
+			if err := w.checkItems(fmt.Sprintf("map %s object %d", name, obj.ID), obj.Properties.GetString("item")); err != nil {
+				return err
+			}
+		}
+		for _, obj := range mapObjects(m, "trigger") {
+			for _, event := range []string{"on_enter", "on_exit"} {
+				conv := obj.Properties.GetString(event)
+				if conv != "" && w.Conversations[conv] == nil {
+					return fmt.Errorf("map %s object %d: %s names unknown dialogue %q", name, obj.ID, event, conv)
+				}
+			}
+		}
+	}
+	for _, def := range w.npcSpawns() {
+		if !def.placed {
+			return fmt.Errorf("npc %q has no npc object on any map", def.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package sim
+
+import "testing"
+
+// pickupOf finds the pickup of an item lying on the current map.
+func pickupOf(w *World, item string) (Pickup, bool) {
+	for _, it := range w.Pickups() {
+		if it.Item == item {
+			return it, true
+		}
+	}
+	return Pickup{}, false
+}
+
+func TestPickups(t *testing.T) {
+	w := newTestWorld(t)
+	wood, ok := pickupOf(w, "wood")
+	if !ok {
+		t.Fatal("no wood lying on the start map")
+	}
+	w.Player.Pos = wood.Pos
+	idle(w, 1)
+	if n := w.CountItem("wood"); n != wood.Count || w.Stats.ItemsGathered != wood.Count {
+		t.Fatalf("%d wood, %d gathered, want %d", n, w.Stats.ItemsGathered, wood.Count)
+	}
+	if _, ok := pickupOf(w, "wood"); ok {
+		t.Fatal("wood still lying there after picking it up")
+	}
+
+	// It stays gone in a restored run, even walking over the spot again
+	got := newTestWorld(t)
+	if err := got.Restore(w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pickupOf(got, "wood"); ok {
+		t.Error("wood back on the map after a restore")
+	}
+	got.Player.Pos = wood.Pos
+	idle(got, 1)
+	if n := got.CountItem("wood"); n != wood.Count {
+		t.Errorf("%d wood after walking over the spot again, want %d", n, wood.Count)
+	}
+}
+
+func TestTriggerOnce(t *testing.T) {
+	w := newTestWorld(t)
+	// The house sign covers tiles 33-36 across, 30-31 down
+	outside, inside := TilePoint{34, 33}.pos(), TilePoint{34, 30}.pos()
+	w.Player.Pos = outside
+	idle(w, 1)
+
+	w.Player.Pos = inside
+	idle(w, 1)
+	if !w.Chatting || w.ConvNode != w.Conversations["house_sign"] {
+		t.Fatal("walking onto the sign didn't start its dialogue")
+	}
+	press(w, Input{Action: true})
+	if w.Chatting {
+		t.Fatal("sign dialogue didn't end")
+	}
+
+	// It only fires once, in this run and in one restored from it
+	w.Player.Pos = outside
+	idle(w, 1)
+	w.Player.Pos = inside
+	idle(w, 1)
+	if w.Chatting {
+		t.Error("sign fired a second time")
+	}
+	got := newTestWorld(t)
+	if err := got.Restore(w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	got.Player.Pos = outside
+	idle(got, 1)
+	got.Player.Pos = inside
+	idle(got, 1)
+	if got.Chatting {
+		t.Error("sign fired again after a restore")
+	}
+}
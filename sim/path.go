@@ -0,0 +1,143 @@
+package sim
+
+import (
+	"container/heap"
+	"image"
+)
+
+// Grid is the walkability of every map tile, built from the collides
+// properties of the map. The world keeps one cached and drops it whenever a
+// tile changes.
+type Grid struct {
+	Width, Height int
+	blocked       []bool
+}
+
+// Walkable reports whether the tile is on the map and free of obstacles.
+func (g *Grid) Walkable(t TilePoint) bool {
+	if t.X < 0 || t.Y < 0 || t.X >= g.Width || t.Y >= g.Height {
+		return false
+	}
+	return !g.blocked[t.Y*g.Width+t.X]
+}
+
+// WalkGrid returns the current walkability grid, rebuilding it if the map
+// changed since the last call.
+func (w *World) WalkGrid() *Grid {
+	if w.grid != nil {
+		return w.grid
+	}
+	g := &Grid{Width: w.Map.Width, Height: w.Map.Height, blocked: make([]bool, w.Map.Width*w.Map.Height)}
+	for i := range g.blocked {
+		g.blocked[i] = w.collidesAt(i)
+	}
+	w.grid = g
+	return g
+}
+
+// invalidateGrid drops the cached grid and every NPC's planned route after
+// the map changed, e.g. when a tree is chopped down.
+func (w *World) invalidateGrid() {
+	w.grid = nil
+	for _, npc := range w.NPCs {
+		npc.path = nil
+	}
+}
+
+// tileAt is the tile under the centre of a character at pos.
+func tileAt(pos image.Point) TilePoint {
+	return TilePoint{(pos.X + TileSize/2) / TileSize, (pos.Y + TileSize/2) / TileSize}
+}
+
+// occupiedBy returns a check for tiles taken by the player or by any NPC on
+// the map other than self, for routing around characters.
+func (w *World) occupiedBy(self *NPC) func(TilePoint) bool {
+	taken := map[TilePoint]bool{tileAt(w.Player.Pos): true}
+	for _, npc := range w.NPCs {
+		if npc != self && !npc.Away {
+			taken[tileAt(npc.Pos)] = true
+			if npc.Moving {
+				taken[tileAt(npc.target)] = true
+			}
+		}
+	}
+	return func(t TilePoint) bool { return taken[t] }
+}
+
+// FindPath runs A* over the walk grid from one tile to another and returns
+// the tiles to walk through, ending with to. Tiles for which avoid returns
+// true count as blocked; avoid may be nil. It returns nil when to cannot be
+// reached or from == to.
+func (w *World) FindPath(from, to TilePoint, avoid func(TilePoint) bool) []TilePoint {
+	g := w.WalkGrid()
+	free := func(t TilePoint) bool {
+		return g.Walkable(t) && (avoid == nil || !avoid(t))
+	}
+	if from == to || !free(to) {
+		return nil
+	}
+	dist := func(a, b TilePoint) int { return abs(a.X-b.X) + abs(a.Y-b.Y) }
+
+	cameFrom := map[TilePoint]TilePoint{}
+	cost := map[TilePoint]int{from: 0}
+	open := &pathQueue{}
+	heap.Push(open, pathNode{t: from, f: dist(from, to)})
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(pathNode)
+		if cur.t == to {
+			var path []TilePoint
+			for t := to; t != from; t = cameFrom[t] {
+				path = append(path, t)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		if cur.g > cost[cur.t] {
+			continue // stale queue entry
+		}
+		for _, d := range npcSteps {
+			next := TilePoint{cur.t.X + d.dx/TileSize, cur.t.Y + d.dy/TileSize}
+			if !free(next) {
+				continue
+			}
+			c := cur.g + 1
+			if old, seen := cost[next]; seen && old <= c {
+				continue
+			}
+			cost[next] = c
+			cameFrom[next] = cur.t
+			heap.Push(open, pathNode{t: next, g: c, f: c + dist(next, to), seq: open.seq})
+			open.seq++
+		}
+	}
+	return nil
+}
+
+type pathNode struct {
+	t    TilePoint
+	g, f int
+	seq  int // insertion order, keeps ties deterministic
+}
+
+// pathQueue is a min-heap of nodes ordered by estimated total cost.
+type pathQueue struct {
+	nodes []pathNode
+	seq   int
+}
+
+func (q *pathQueue) Len() int { return len(q.nodes) }
+func (q *pathQueue) Less(i, j int) bool {
+	if q.nodes[i].f != q.nodes[j].f {
+		return q.nodes[i].f < q.nodes[j].f
+	}
+	return q.nodes[i].seq < q.nodes[j].seq
+}
+func (q *pathQueue) Swap(i, j int) { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *pathQueue) Push(x any)    { q.nodes = append(q.nodes, x.(pathNode)) }
+func (q *pathQueue) Pop() any {
+	n := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return n
+}
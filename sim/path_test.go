@@ -0,0 +1,116 @@
+package sim
+
+import (
+	"image"
+	"testing"
+)
+
+// gridWorld builds a world around a walk grid drawn in rows: '#' is blocked,
+// A is where the route starts and B where it ends, X a blocked goal, P the
+// player, N an NPC, M an NPC standing on the goal and S the NPC doing the
+// routing. Anywhere else is free. Without a goal the route ends at A.
+func gridWorld(rows []string) (w *World, from, to TilePoint, self *NPC) {
+	w = &World{Player: Player{Pos: image.Point{X: -10 * TileSize, Y: -10 * TileSize}}}
+	g := &Grid{Width: len(rows[0]), Height: len(rows), blocked: make([]bool, len(rows[0])*len(rows))}
+	for y, row := range rows {
+		for x, c := range row {
+			t, pos := TilePoint{x, y}, image.Point{X: x * TileSize, Y: y * TileSize}
+			switch c {
+			case '#':
+				g.blocked[y*g.Width+x] = true
+			case 'A':
+				from = t
+			case 'B':
+				to = t
+			case 'X':
+				to = t
+				g.blocked[y*g.Width+x] = true
+			case 'P':
+				w.Player.Pos = pos
+			case 'N', 'M', 'S':
+				if c == 'M' {
+					to = t
+				}
+				npc := &NPC{Name: string(c), Pos: pos}
+				w.NPCs = append(w.NPCs, npc)
+				if c == 'S' {
+					self = npc
+				}
+			}
+		}
+	}
+	w.grid = g
+	return w, from, to, self
+}
+
+func TestFindPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  []string
+		avoid bool // route around the player and NPCs
+		steps int  // length of the shortest route, 0 when there is none
+	}{
+		{"straight", []string{"A...B"}, false, 4},
+		{"around a wall", []string{
+			"A.#.B",
+			"..#..",
+			".....",
+		}, false, 8},
+		{"walled off", []string{
+			"A.#..",
+			"..#.B",
+			"..#..",
+		}, false, 0},
+		{"blocked goal", []string{"A..X"}, false, 0},
+		{"already there", []string{"A.."}, false, 0},
+		{"through an NPC", []string{
+			"A.N.B",
+			".....",
+		}, false, 4},
+		{"detour around an NPC", []string{
+			"A.N.B",
+			".....",
+		}, true, 6},
+		{"detour around the player", []string{
+			"A.P.B",
+			".....",
+		}, true, 6},
+		{"corridor blocked by an NPC", []string{
+			"#####",
+			"A.N.B",
+			"#####",
+		}, true, 0},
+		{"not in its own way", []string{
+			"#####",
+			"A.S.B",
+			"#####",
+		}, true, 4},
+		{"goal taken by an NPC", []string{"A...M"}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, from, to, self := gridWorld(tt.rows)
+			var avoid func(TilePoint) bool
+			if tt.avoid {
+				avoid = w.occupiedBy(self)
+			}
+			path := w.FindPath(from, to, avoid)
+			if len(path) != tt.steps {
+				t.Fatalf("route %v has %d steps, want %d", path, len(path), tt.steps)
+			}
+			cur := from
+			for _, next := range path {
+				if abs(next.X-cur.X)+abs(next.Y-cur.Y) != 1 {
+					t.Fatalf("route %v jumps from %v to %v", path, cur, next)
+				}
+				if !w.grid.Walkable(next) || avoid != nil && avoid(next) {
+					t.Fatalf("route %v goes through %v", path, next)
+				}
+				cur = next
+			}
+			if len(path) > 0 && cur != to {
+				t.Errorf("route %v ends at %v, want %v", path, cur, to)
+			}
+		})
+	}
+}
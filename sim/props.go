@@ -0,0 +1,94 @@
+package sim
+
+import (
+	"strconv"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// Map authors control how tiles behave with Tiled custom properties, set on a
+// whole layer or on individual tiles in the tileset; a tile's own property
+// wins over its layer's:
+//
+//	collides     bool    the tile blocks movement
+//	interaction  string  what facing the tile and pressing Space does: "door"
+//	                     goes through a portal, anything else starts the
+//	                     conversation of that name, e.g. "fish" or "chop"
+//	walk_speed   float   multiplies the player's speed on the tile
+//	above        bool    the tile is drawn in depth order with characters,
+//	                     so they can walk behind it (tree canopies, roofs)
+//
+// For the gameplay properties the topmost visible layer that has a tile
+// setting it decides, so a bridge layer with collides=false makes the water
+// beneath it walkable, while decorations without properties (e.g. windows)
+// leave the wall under them solid. "above" only affects the tile it is set on.
+
+// tileProp looks up a property for the tile at idx on layer.
+func tileProp(layer *tiled.Layer, idx int, name string) (string, bool) {
+	if !hasTile(layer, idx) {
+		return "", false
+	}
+	tile := layer.Tiles[idx]
+	if tt, err := tile.Tileset.GetTilesetTile(tile.ID); err == nil {
+		if v := tt.Properties.Get(name); len(v) > 0 {
+			return v[0], true
+		}
+	}
+	if v := layer.Properties.Get(name); len(v) > 0 {
+		return v[0], true
+	}
+	return "", false
+}
+
+// topProp finds the topmost visible layer setting a property at idx.
+func (w *World) topProp(idx int, name string) (*tiled.Layer, string, bool) {
+	for i := len(w.Map.Layers) - 1; i >= 0; i-- {
+		layer := w.Map.Layers[i]
+		if !layer.Visible {
+			continue
+		}
+		if v, ok := tileProp(layer, idx, name); ok {
+			return layer, v, true
+		}
+	}
+	return nil, "", false
+}
+
+// collidesAt reports whether the map tile at idx blocks movement.
+func (w *World) collidesAt(idx int) bool {
+	_, v, _ := w.topProp(idx, "collides")
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// interactionAt returns the interaction of the tile at idx and the layer it
+// comes from, or "" if there is none.
+func (w *World) interactionAt(idx int) (*tiled.Layer, string) {
+	layer, v, _ := w.topProp(idx, "interaction")
+	return layer, v
+}
+
+// walkSpeedAt returns the speed multiplier of the tile at idx, 1 by default
+// and off the map.
+func (w *World) walkSpeedAt(idx int) float64 {
+	if idx < 0 {
+		return 1
+	}
+	_, v, ok := w.topProp(idx, "walk_speed")
+	if !ok {
+		return 1
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 1
+	}
+	return f
+}
+
+// DrawsAbove reports whether the tile at idx on layer is flagged "above" and
+// so should be depth-sorted against characters rather than drawn under them.
+func DrawsAbove(layer *tiled.Layer, idx int) bool {
+	v, _ := tileProp(layer, idx, "above")
+	b, _ := strconv.ParseBool(v)
+	return b
+}
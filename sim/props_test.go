@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// overlap finds a tile of the current map with tiles on both layers.
+func overlap(t *testing.T, w *World, top, under string) int {
+	t.Helper()
+	a, b := w.layer(top), w.layer(under)
+	for idx := range a.Tiles {
+		if hasTile(a, idx) && hasTile(b, idx) {
+			return idx
+		}
+	}
+	t.Fatalf("no %s over %s on %s", top, under, w.MapName)
+	return 0
+}
+
+func TestCollidesUnderLayers(t *testing.T) {
+	w := newTestWorld(t)
+
+	// A bridge says collides=false, so the water under it is walkable
+	bridge := overlap(t, w, "Bridges", "Water")
+	if w.collidesAt(bridge) {
+		t.Error("bridge over water collides")
+	}
+	if layer, _ := w.interactionAt(bridge); layer != w.layer("Bridges") {
+		t.Error("interaction on a bridge doesn't come from Bridges")
+	}
+	w.layer("Bridges").Visible = false
+	if !w.collidesAt(bridge) {
+		t.Error("water with the bridges hidden doesn't collide")
+	}
+
+	// A window says nothing about collides, so the wall under it decides
+	window := overlap(t, w, "Windows", "Buildings")
+	if layer, _, _ := w.topProp(window, "collides"); layer != w.layer("Buildings") {
+		t.Error("collides on a window doesn't come from Buildings")
+	}
+	if !w.collidesAt(window) {
+		t.Error("wall under a window doesn't collide")
+	}
+}
+
+func TestDrawsAbove(t *testing.T) {
+	prop := func(v string) tiled.Properties {
+		return tiled.Properties{{Name: "above", Type: "bool", Value: v}}
+	}
+	ts := &tiled.Tileset{Tiles: []*tiled.TilesetTile{
+		{ID: 1, Properties: prop("false")},
+		{ID: 2, Properties: prop("true")},
+	}}
+	tiles := []*tiled.LayerTile{
+		{ID: 0, Tileset: ts}, // no tile property
+		{ID: 1, Tileset: ts},
+		{ID: 2, Tileset: ts},
+		{Nil: true},
+	}
+	tests := []struct {
+		name  string
+		layer tiled.Properties
+		want  []bool
+	}{
+		{"layer above", prop("true"), []bool{true, false, true, false}},
+		{"layer not above", prop("false"), []bool{false, false, true, false}},
+		{"layer unset", nil, []bool{false, false, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := &tiled.Layer{Properties: tt.layer, Tiles: tiles}
+			for idx, want := range tt.want {
+				if got := DrawsAbove(layer, idx); got != want {
+					t.Errorf("tile %d: DrawsAbove = %v, want %v", idx, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type ItemCount struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// Recipe turns inputs into outputs after Minutes of in-game time. Station
+// names what the player has to be standing at, e.g. "fire" for a burning
+// campfire; empty means anywhere.
+type Recipe struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Inputs  []ItemCount `json:"inputs"`
+	Outputs []ItemCount `json:"outputs"`
+	Station string      `json:"station,omitempty"`
+	Minutes int         `json:"minutes,omitempty"`
+}
+
+// CraftJob is a recipe in progress. Inputs are taken when it starts and
+// outputs are handed over once MinutesLeft reaches zero and they all fit in
+// the inventory. A recipe with a Station only counts down while the player
+// stays at a working one. Blocked says why a job is held up, "" while it
+// goes on.
+type CraftJob struct {
+	Recipe      *Recipe
+	MinutesLeft int
+	Blocked     string
+}
+
+// LoadRecipes reads the recipe registry from a JSON file.
+func LoadRecipes(path string) ([]*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Recipes []*Recipe `json:"recipes"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, r := range f.Recipes {
+		switch {
+		case r.ID == "":
+			return nil, fmt.Errorf("%s: recipe %q has no id", path, r.Name)
+		case seen[r.ID]:
+			return nil, fmt.Errorf("%s: duplicate recipe id %q", path, r.ID)
+		case len(r.Inputs) == 0 || len(r.Outputs) == 0:
+			return nil, fmt.Errorf("%s: recipe %q needs inputs and outputs", path, r.ID)
+		}
+		seen[r.ID] = true
+		if r.Name == "" {
+			r.Name = r.ID
+		}
+	}
+	return f.Recipes, nil
+}
+
+// CanCraft reports whether the player has the inputs and station for r.
+func (w *World) CanCraft(r *Recipe) bool {
+	if !w.nearStation(r.Station) {
+		return false
+	}
+	for _, in := range r.Inputs {
+		if !w.HasItem(in.Item, in.Count) {
+			return false
+		}
+	}
+	return true
+}
+
+// CraftableRecipes lists every recipe the player can make right now, none
+// while something is already being made.
+func (w *World) CraftableRecipes() []*Recipe {
+	if w.Crafting != nil {
+		return nil
+	}
+	var out []*Recipe
+	for _, r := range w.Recipes {
+		if w.CanCraft(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// startCraft takes the inputs for r and queues its outputs.
+func (w *World) startCraft(r *Recipe) bool {
+	if w.Crafting != nil || !w.CanCraft(r) {
+		return false
+	}
+	for _, in := range r.Inputs {
+		w.RemoveItem(in.Item, in.Count)
+	}
+	w.Crafting = &CraftJob{Recipe: r, MinutesLeft: max(r.Minutes, 0)}
+	if r.Minutes <= 0 {
+		w.finishCraft()
+	}
+	return true
+}
+
+// finishCraft hands over the outputs of the job, or holds on to them until
+// there is room for all of them together, like harvesting a crop.
+func (w *World) finishCraft() {
+	job := w.Crafting
+	bag := w.Inventory
+	for _, out := range job.Recipe.Outputs {
+		if w.AddToInventory(out.Item, out.Count) > 0 {
+			w.Inventory = bag // put back what the earlier outputs took
+			w.block(job, "No room in your inventory for the "+job.Recipe.Name+".")
+			return
+		}
+	}
+	w.Crafting = nil
+}
+
+// block holds up a job, telling the player why the first time.
+func (w *World) block(job *CraftJob, why string) {
+	if job.Blocked != why {
+		w.notify(why)
+	}
+	job.Blocked = why
+}
+
+// stepCrafting runs once per in-game minute.
+func (w *World) stepCrafting() {
+	job := w.Crafting
+	if job == nil {
+		return
+	}
+	if job.MinutesLeft > 0 {
+		if !w.nearStation(job.Recipe.Station) {
+			w.block(job, job.Recipe.Name+" is paused until you are back at a "+job.Recipe.Station+".")
+			return
+		}
+		job.Blocked = ""
+		job.MinutesLeft--
+	}
+	if job.MinutesLeft == 0 {
+		w.finishCraft()
+	}
+}
+
+func (w *World) recipe(id string) *Recipe {
+	for _, r := range w.Recipes {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
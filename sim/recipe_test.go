@@ -0,0 +1,66 @@
+package sim
+
+import "testing"
+
+func TestFinishCraftRoom(t *testing.T) {
+	// Each output fills a whole slot, so alone either fits in one free slot
+	kit := &Recipe{ID: "kit", Name: "Kit", Outputs: []ItemCount{{"wood", 20}, {"stone", 20}}}
+	tests := []struct {
+		name string
+		free int // empty inventory slots
+		done bool
+	}{
+		{"no room", 0, false},
+		{"room for one output", 1, false},
+		{"room for both", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.AddToInventory(Currency, (64-tt.free)*w.Items[Currency].MaxStack)
+			bag := w.Inventory
+			w.Crafting = &CraftJob{Recipe: kit}
+			w.finishCraft()
+			if done := w.Crafting == nil; done != tt.done {
+				t.Fatalf("done = %v, want %v", done, tt.done)
+			}
+			if !tt.done {
+				if w.Inventory != bag {
+					t.Error("a held up craft changed the inventory")
+				}
+				if w.Crafting.Blocked == "" || w.TakeNotice() == "" {
+					t.Error("a held up craft didn't say why")
+				}
+				return
+			}
+			for _, out := range kit.Outputs {
+				if n := w.CountItem(out.Item); n != out.Count {
+					t.Errorf("%d %s, want %d", n, out.Item, out.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestCraftableWhileBusy(t *testing.T) {
+	w := newTestWorld(t)
+	w.AddToInventory("wood", 4)
+	torch := w.recipe("torch")
+	if got := w.CraftableRecipes(); len(got) != 1 || got[0] != torch {
+		t.Fatalf("craftable %v, want the torch", got)
+	}
+	if !w.startCraft(torch) {
+		t.Fatal("torch not started")
+	}
+	// Wood for a second torch is left, but one thing is made at a time
+	if got := w.CraftableRecipes(); len(got) != 0 {
+		t.Errorf("%d craftable while making a torch, want none", len(got))
+	}
+	idle(w, torch.Minutes*ticksPerGameMinute)
+	if w.Crafting != nil {
+		t.Fatal("torch still being made")
+	}
+	if got := w.CraftableRecipes(); len(got) != 1 {
+		t.Errorf("%d craftable after the torch, want 1", len(got))
+	}
+}
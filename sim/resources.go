@@ -0,0 +1,185 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// ResourceDef describes a harvestable map feature such as a tree, a berry
+// bush or a rock. A node is recognised by the GIDs of its tiles, listed top
+// to bottom for each look it can have; the last tile is its base. Harvesting
+// clears the node, leaves Harvested at the base (e.g. a stump) and grows the
+// original tiles back after RegrowDays in-game midnights.
+type ResourceDef struct {
+	ID         string     `json:"id"`
+	Variants   [][]uint32 `json:"variants"`
+	Harvested  uint32     `json:"harvested,omitempty"` // GID left at the base, 0 for nothing
+	RegrowDays int        `json:"regrowDays"`
+}
+
+// Regrowth is a harvested node waiting to grow back.
+type Regrowth struct {
+	Resource string `json:"resource"`
+	Map      string `json:"map"`
+	Layer    string `json:"layer"`
+	Index    int    `json:"index"` // base tile
+	Height   int    `json:"height"`
+	DaysLeft int    `json:"daysLeft"`
+}
+
+// LoadResources reads the resource node registry from a JSON file.
+func LoadResources(path string) ([]*ResourceDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Resources []*ResourceDef `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, r := range f.Resources {
+		switch {
+		case r.ID == "":
+			return nil, fmt.Errorf("%s: resource has no id", path)
+		case seen[r.ID]:
+			return nil, fmt.Errorf("%s: duplicate resource id %q", path, r.ID)
+		case len(r.Variants) == 0:
+			return nil, fmt.Errorf("%s: resource %q has no variants", path, r.ID)
+		case r.RegrowDays < 0:
+			return nil, fmt.Errorf("%s: resource %q has negative regrowDays", path, r.ID)
+		}
+		for _, v := range r.Variants {
+			if len(v) == 0 {
+				return nil, fmt.Errorf("%s: resource %q has an empty variant", path, r.ID)
+			}
+		}
+		seen[r.ID] = true
+	}
+	return f.Resources, nil
+}
+
+// resourceAt finds the node one of whose tiles is at idx on a layer of the
+// current map, and returns it with the index of its base tile and height.
+func (w *World) resourceAt(layer *tiled.Layer, idx int) (*ResourceDef, int, int) {
+	width := w.Map.Width
+	gidAt := func(i int) uint32 {
+		if i < 0 || i >= len(layer.Tiles) {
+			return 0
+		}
+		return layerTileGID(layer.Tiles[i]) &^ gidFlipBits // mirrored nodes count too
+	}
+	gid := gidAt(idx)
+	if gid == 0 {
+		return nil, 0, 0
+	}
+	for _, def := range w.Resources {
+		for _, v := range def.Variants {
+			for k, g := range v {
+				if g != gid {
+					continue
+				}
+				base := idx + (len(v)-1-k)*width
+				top := base - (len(v)-1)*width
+				match := true
+				for i, want := range v {
+					match = match && gidAt(top+i*width) == want
+				}
+				if match {
+					return def, base, len(v)
+				}
+			}
+		}
+	}
+	return nil, 0, 0
+}
+
+// harvest clears the node at idx on the named layer of the current map and
+// schedules its regrowth. It reports whether there was a node to harvest.
+func (w *World) harvest(layerName string, idx int) bool {
+	layer := w.layer(layerName)
+	if layer == nil {
+		return false
+	}
+	def, base, height := w.resourceAt(layer, idx)
+	if def == nil {
+		return false
+	}
+	for i := range height {
+		gid := uint32(0)
+		if i == 0 {
+			gid = def.Harvested
+		}
+		w.SetTile(layerName, base-i*w.Map.Width, gid)
+	}
+	w.regrowing = append(w.regrowing, &Regrowth{
+		Resource: def.ID,
+		Map:      w.MapName,
+		Layer:    layerName,
+		Index:    base,
+		Height:   height,
+		DaysLeft: def.RegrowDays,
+	})
+	return true
+}
+
+// stepRegrowth runs once per in-game minute and counts down the days of
+// every harvested node at midnight. Due nodes grow back as soon as nobody
+// stands on them.
+func (w *World) stepRegrowth() {
+	midnight := w.GameMinutes == 0
+	kept := w.regrowing[:0]
+	for _, r := range w.regrowing {
+		if midnight {
+			r.DaysLeft--
+		}
+		if r.DaysLeft > 0 || !w.regrow(r) {
+			kept = append(kept, r)
+		}
+	}
+	w.regrowing = kept
+}
+
+func (w *World) regrow(r *Regrowth) bool {
+	m := w.maps[r.Map]
+	if m == nil {
+		return false
+	}
+	for i := range r.Height {
+		idx := r.Index - i*m.Width
+		if w.standingOn(r.Map, TilePoint{idx % m.Width, idx / m.Width}) {
+			return false
+		}
+	}
+	for i := range r.Height {
+		w.restoreTile(r.Map, r.Layer, r.Index-i*m.Width)
+	}
+	return true
+}
+
+// standingOn reports whether the player or an NPC is on a tile of a map.
+func (w *World) standingOn(mapName string, t TilePoint) bool {
+	if mapName == w.MapName && tileAt(w.Player.Pos) == t {
+		return true
+	}
+	for _, npc := range w.npcsByMap[mapName] {
+		if !npc.Away && (tileAt(npc.Pos) == t || npc.Moving && tileAt(npc.target) == t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Regrowing lists the harvested nodes that have not grown back yet.
+func (w *World) Regrowing() []Regrowth {
+	list := make([]Regrowth, len(w.regrowing))
+	for i, r := range w.regrowing {
+		list[i] = *r
+	}
+	return list
+}
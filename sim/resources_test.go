@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"slices"
+	"testing"
+)
+
+// passMidnight runs the clock on from 23:59 to 00:00.
+func passMidnight(w *World) {
+	w.GameMinutes = MinutesPerDay - 1
+	w.minuteTicks = 0
+	idle(w, ticksPerGameMinute)
+}
+
+func TestRegrowth(t *testing.T) {
+	tests := []struct {
+		name      string
+		layer, id string
+		days      int
+		gids      []uint32 // base first, as harvested
+	}{
+		{"berry bush", "Resources", "berry_bush", 1, []uint32{530}},
+		{"rock", "Resources", "rock", 5, []uint32{0}},
+		{"tree", "Trees", "tree", 3, []uint32{1118, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			base := findResource(t, w, tt.layer, tt.id)
+			layer := w.layer(tt.layer)
+			gidsAt := func() []uint32 {
+				gids := make([]uint32, len(tt.gids))
+				for i := range gids {
+					gids[i] = layerTileGID(layer.Tiles[base-i*w.Map.Width])
+				}
+				return gids
+			}
+			grown := gidsAt()
+
+			if !w.harvest(tt.layer, base) {
+				t.Fatal("nothing harvested")
+			}
+			if got := gidsAt(); !slices.Equal(got, tt.gids) {
+				t.Fatalf("harvested tiles %v, want %v", got, tt.gids)
+			}
+			if w.harvest(tt.layer, base) {
+				t.Fatal("harvested the same node twice")
+			}
+			for day := 1; day < tt.days; day++ {
+				passMidnight(w)
+				if got := gidsAt(); !slices.Equal(got, tt.gids) {
+					t.Fatalf("grew back after %d of %d days", day, tt.days)
+				}
+			}
+
+			// Due, but the player is in the way
+			w.Player.Pos = TilePoint{base % w.Map.Width, base / w.Map.Width}.pos()
+			passMidnight(w)
+			if len(w.Regrowing()) != 1 || !slices.Equal(gidsAt(), tt.gids) {
+				t.Fatal("grew back under the player")
+			}
+			w.Player.Pos = w.playerSpawn()
+			idle(w, ticksPerGameMinute)
+			if got := gidsAt(); !slices.Equal(got, grown) {
+				t.Errorf("tiles %v once the player moved off, want %v", got, grown)
+			}
+			if n := len(w.Regrowing()); n != 0 {
+				t.Errorf("%d nodes still regrowing", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SaveVersion is bumped when older saves can't be read as they are and
+// migrate has to rewrite them. Restore refuses saves written by a newer
+// version and upgrades older ones in migrate.
+//
+// Fields that older saves simply lack, such as Flags, Stats, Shops,
+// Crafting, PickedUp and an NPC's Away, load as their zero value and need no
+// bump. Versions 4 to 8 mark additions of that kind as well; they only
+// record when each field arrived.
+//
+//	1  initial format
+//	2  inventory and shop stock store item IDs instead of display names
+//	3  the current map is saved and tile edits and NPCs name their map
+//	4  the seed of procedural maps is saved
+//	5  harvested resource nodes remember when they regrow
+//	6  farm plots and their crops are saved
+//	7  placed structures are saved
+//	8  campfires remember how long they have left to burn
+const SaveVersion = 8
+
+// AutosaveSlot is the slot written by the in-game autosave.
+const AutosaveSlot = "autosave"
+
+type SaveData struct {
+	Version     int                       `json:"version"`
+	Map         string                    `json:"map"`
+	Seed        int64                     `json:"seed,omitempty"`
+	Player      SavedCharacter            `json:"player"`
+	NPCs        []SavedCharacter          `json:"npcs"`
+	Inventory   [8][8]InventorySlot       `json:"inventory"`
+	Health      float64                   `json:"health"`
+	Social      float64                   `json:"social"`
+	Hunger      float64                   `json:"hunger"`
+	GameMinutes int                       `json:"gameMinutes"`
+	Tiles       []TileEdit                `json:"tiles"`
+	Flags       map[string]bool           `json:"flags,omitempty"`
+	Stats       Stats                     `json:"stats"`
+	Shops       map[string]map[string]int `json:"shops,omitempty"` // stock left per NPC shop
+	Crafting    *SavedCraft               `json:"crafting,omitempty"`
+	PickedUp    []string                  `json:"pickedUp,omitempty"` // pickups collected, as "map:objectID"
+	Regrowing   []Regrowth                `json:"regrowing,omitempty"`
+	Plots       []Plot                    `json:"plots,omitempty"`
+	Structures  []Structure               `json:"structures,omitempty"`
+}
+
+type SavedCraft struct {
+	Recipe      string `json:"recipe"`
+	MinutesLeft int    `json:"minutesLeft"`
+}
+
+type SavedCharacter struct {
+	Name string `json:"name,omitempty"`
+	Map  string `json:"map,omitempty"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Dir  int    `json:"dir"`
+	Away bool   `json:"away,omitempty"`
+}
+
+// Snapshot captures everything needed to resume the world later.
+func (w *World) Snapshot() *SaveData {
+	d := &SaveData{
+		Version:     SaveVersion,
+		Map:         w.MapName,
+		Seed:        w.Seed,
+		Player:      SavedCharacter{X: w.Player.Pos.X, Y: w.Player.Pos.Y, Dir: w.Player.Dir},
+		Inventory:   w.Inventory,
+		Health:      w.Health,
+		Social:      w.Social,
+		Hunger:      w.Hunger,
+		GameMinutes: w.GameMinutes,
+		Tiles:       w.TileEdits(),
+		Flags:       w.Flags,
+		Stats:       w.Stats,
+		Shops:       map[string]map[string]int{},
+		PickedUp:    w.PickedUp(),
+		Regrowing:   w.Regrowing(),
+		Plots:       w.Plots(),
+		Structures:  w.Structures(),
+	}
+	for name, shop := range w.Shops {
+		d.Shops[name] = shop.Stock
+	}
+	if w.Crafting != nil {
+		d.Crafting = &SavedCraft{Recipe: w.Crafting.Recipe.ID, MinutesLeft: w.Crafting.MinutesLeft}
+	}
+	for _, npc := range w.allNPCs() {
+		pos := npc.Pos
+		if npc.Moving {
+			pos = npc.target // save on the grid, not halfway through a step
+		}
+		d.NPCs = append(d.NPCs, SavedCharacter{Name: npc.Name, Map: npc.def.onMap, X: pos.X, Y: pos.Y, Dir: npc.Dir, Away: npc.Away})
+	}
+	return d
+}
+
+// Restore resets the world and applies a snapshot on top of it. A save that
+// names a map or tile the game can't load is refused before anything is
+// reset, so a failed load leaves the running game untouched.
+func (w *World) Restore(d *SaveData) error {
+	if d.Version > SaveVersion {
+		return fmt.Errorf("save version %d is newer than supported version %d", d.Version, SaveVersion)
+	}
+	w.migrate(d)
+	if err := w.checkSave(d); err != nil {
+		return err
+	}
+	w.Reset()
+	if d.Seed != 0 {
+		w.Seed = d.Seed // older saves never saw a procedural map, any seed will do
+	}
+	if d.Map != w.MapName {
+		m, err := w.mapByName(d.Map)
+		if err != nil {
+			return err
+		}
+		w.setMap(d.Map, m)
+	}
+	w.Player.Pos.X, w.Player.Pos.Y, w.Player.Dir = d.Player.X, d.Player.Y, d.Player.Dir
+	for _, saved := range d.NPCs {
+		for _, npc := range w.allNPCs() {
+			if npc.Name == saved.Name {
+				npc.Pos.X, npc.Pos.Y, npc.Dir = saved.X, saved.Y, saved.Dir
+				npc.Away = saved.Away
+			}
+		}
+	}
+	w.Inventory = d.Inventory
+	w.Health = d.Health
+	w.Social = d.Social
+	w.Hunger = d.Hunger
+	w.GameMinutes = d.GameMinutes % MinutesPerDay
+	w.Flags = d.Flags
+	w.Stats = d.Stats
+	for _, key := range d.PickedUp {
+		w.pickedUp[key] = true
+	}
+	for name, stock := range d.Shops {
+		if shop := w.Shops[name]; shop != nil {
+			for item, n := range stock {
+				shop.Stock[item] = n
+			}
+		}
+	}
+	if d.Crafting != nil {
+		if r := w.recipe(d.Crafting.Recipe); r != nil {
+			w.Crafting = &CraftJob{Recipe: r, MinutesLeft: d.Crafting.MinutesLeft}
+		}
+	}
+	for _, e := range d.Tiles {
+		if err := w.setTileOn(e.Map, e.Layer, e.Index, e.GID); err != nil {
+			return fmt.Errorf("restore tile %s/%s[%d]: %w", e.Map, e.Layer, e.Index, err)
+		}
+	}
+	for _, r := range d.Regrowing {
+		w.regrowing = append(w.regrowing, &r)
+	}
+	for _, p := range d.Plots {
+		if w.plots == nil {
+			w.plots = map[tileRef]*Plot{}
+		}
+		w.plots[tileRef{p.Map, p.Index}] = &p
+	}
+	for _, st := range d.Structures {
+		if w.structures == nil {
+			w.structures = map[tileRef]*Structure{}
+		}
+		w.structures[tileRef{st.Map, st.Index}] = &st
+	}
+	w.updateWeather()
+	return nil
+}
+
+// checkSave loads every map a save refers to and checks that each tile edit
+// names a layer, index and GID of its map, the only things Restore can fail
+// on.
+func (w *World) checkSave(d *SaveData) error {
+	if _, err := w.mapByName(d.Map); err != nil {
+		return err
+	}
+	for _, e := range d.Tiles {
+		m, err := w.mapByName(e.Map)
+		if err == nil {
+			_, _, err = editTile(m, e.Layer, e.Index, e.GID)
+		}
+		if err != nil {
+			return fmt.Errorf("restore tile %s/%s[%d]: %w", e.Map, e.Layer, e.Index, err)
+		}
+	}
+	return nil
+}
+
+// migrate upgrades an older save in place to the current version.
+func (w *World) migrate(d *SaveData) {
+	if d.Version < 2 {
+		toID := func(name string) string {
+			if def := w.itemByName(name); def != nil {
+				return def.ID
+			}
+			return name
+		}
+		for y := range d.Inventory {
+			for x := range d.Inventory[y] {
+				d.Inventory[y][x].Item = toID(d.Inventory[y][x].Item)
+			}
+		}
+		for npc, stock := range d.Shops {
+			migrated := map[string]int{}
+			for item, n := range stock {
+				migrated[toID(item)] = n
+			}
+			d.Shops[npc] = migrated
+		}
+	}
+	if d.Version < 3 {
+		d.Map = w.StartMap
+		for i := range d.Tiles {
+			d.Tiles[i].Map = w.StartMap
+		}
+	}
+	d.Version = SaveVersion
+}
+
+// AutosaveDue reports whether the autosave interval has elapsed since the
+// last call that returned true.
+func (w *World) AutosaveDue() bool {
+	due := w.autosaveDue
+	w.autosaveDue = false
+	return due
+}
+
+// SaveSlot writes the world to dir/<slot>.json.
+func (w *World) SaveSlot(dir, slot string) error {
+	path, err := slotPath(dir, slot)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(w.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temp file first so a crash never leaves a half-written save
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSlot reads dir/<slot>.json.
+func LoadSlot(dir, slot string) (*SaveData, error) {
+	path, err := slotPath(dir, slot)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &SaveData{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("parse save %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// ListSlots returns the names of all saves in dir, sorted.
+func ListSlots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var slots []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			slots = append(slots, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(slots)
+	return slots, nil
+}
+
+func slotPath(dir, slot string) (string, error) {
+	if slot == "" || strings.ContainsAny(slot, `/\`) || slot == "." || slot == ".." {
+		return "", fmt.Errorf("invalid save slot name %q", slot)
+	}
+	return filepath.Join(dir, slot+".json"), nil
+}
@@ -0,0 +1,170 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+const treeIndex = 713 // a tree on the Trees layer of the start map
+
+func TestSaveRoundTrip(t *testing.T) {
+	w := newTestWorld(t)
+	w.AddToInventory("wood", 7)
+	w.AddToInventory("cooked_fish", 3)
+	if err := w.SetTile("Trees", treeIndex, 0); err != nil {
+		t.Fatal(err)
+	}
+	plot, fire := 1100, 1102
+	tomato := w.crop("tomato")
+	if err := w.SetTile(baseGroundLayer, plot, w.Farm.Soil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetTile(cropsLayer, plot, tomato.Stages[1]); err != nil {
+		t.Fatal(err)
+	}
+	w.plots = map[tileRef]*Plot{{w.MapName, plot}: {Map: w.MapName, Index: plot, Crop: tomato.ID, Stage: 1, Days: 1, Watered: true}}
+	campfire := w.buildable("campfire")
+	if err := w.SetTile(structuresLayer, fire, campfire.Tile); err != nil {
+		t.Fatal(err)
+	}
+	w.structures = map[tileRef]*Structure{{w.MapName, fire}: {Buildable: campfire.ID, Map: w.MapName, Index: fire, Fuel: 42}}
+	w.Flags = map[string]bool{"fed_kid": true}
+	w.Health, w.Hunger, w.Social = 0.9, 0.4, 0.6
+	// A month in, past the end of spring
+	w.Stats.MinutesSurvived = 30*MinutesPerDay + 200
+	w.GameMinutes = (8*60 + w.Stats.MinutesSurvived) % MinutesPerDay
+	w.updateWeather()
+
+	dir := t.TempDir()
+	if err := w.SaveSlot(dir, "slot1"); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadSlot(dir, "slot1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := newTestWorld(t)
+	got.Reset() // a different run with a different seed
+	if err := got.Restore(d); err != nil {
+		t.Fatal(err)
+	}
+
+	wantRise, wantSet := w.Daylight()
+	gotRise, gotSet := got.Daylight()
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"seed", got.Seed, w.Seed},
+		{"inventory", got.Inventory, w.Inventory},
+		{"tile edits", got.TileEdits(), w.TileEdits()},
+		{"chopped tree", got.layer("Trees").Tiles[treeIndex].Nil, true},
+		{"crop tile", layerTileGID(got.layer(cropsLayer).Tiles[plot]), tomato.Stages[1]},
+		{"plots", got.Plots(), w.Plots()},
+		{"structures", got.Structures(), w.Structures()},
+		{"flags", got.Flags, w.Flags},
+		{"bars", [3]float64{got.Health, got.Hunger, got.Social}, [3]float64{0.9, 0.4, 0.6}},
+		{"clock", got.GameMinutes, w.GameMinutes},
+		{"date", got.Date(), w.Date()},
+		{"daylight", [2]int{gotRise, gotSet}, [2]int{wantRise, wantSet}},
+		{"weather", got.Weather, w.Weather},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if got.Date().Season != Autumn {
+		t.Errorf("season = %v, want autumn", got.Date().Season)
+	}
+}
+
+func TestSaveMigrateV1(t *testing.T) {
+	w := newTestWorld(t)
+	d, err := LoadSlot("testdata", "save_v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Restore(d); err != nil {
+		t.Fatal(err)
+	}
+	var kid *NPC
+	for _, npc := range w.NPCs {
+		if npc.Name == "Kid" {
+			kid = npc
+		}
+	}
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"version", d.Version, SaveVersion},
+		{"map", w.MapName, w.StartMap},
+		{"wood", w.Inventory[0][0], InventorySlot{Item: "wood", Count: 4}},
+		{"cooked fish", w.Inventory[0][1], InventorySlot{Item: "cooked_fish", Count: 2}},
+		{"coins", w.Inventory[1][0], InventorySlot{Item: Currency, Count: 12}},
+		{"bread stock", w.Shops["Merchant"].Stock["bread"], 1},
+		{"cooked fish stock", w.Shops["Merchant"].Stock["cooked_fish"], 0},
+		{"tile edits", w.TileEdits(), []TileEdit{{Map: w.StartMap, Layer: "Trees", Index: treeIndex, GID: 0}}},
+		{"chopped tree", w.layer("Trees").Tiles[treeIndex].Nil, true},
+		{"player", [3]int{w.Player.Pos.X, w.Player.Pos.Y, w.Player.Dir}, [3]int{300, 315, 2}},
+		{"kid", [3]int{kid.Pos.X, kid.Pos.Y, kid.Dir}, [3]int{405, 345, 1}},
+		{"bars", [3]float64{w.Health, w.Social, w.Hunger}, [3]float64{0.8, 0.7, 0.6}},
+		{"clock", w.GameMinutes, 600},
+		{"flags", w.Flags, map[string]bool{"fed_kid": true}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestSaveRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoil func(d *SaveData)
+	}{
+		{"newer version", func(d *SaveData) { d.Version = SaveVersion + 1 }},
+		{"unknown map", func(d *SaveData) { d.Map = "nowhere" }},
+		// Bad edits go first so a restore that fails halfway would be caught
+		// missing the good one
+		{"tile on unknown map", func(d *SaveData) {
+			d.Tiles = append([]TileEdit{{Map: "nowhere", Layer: "Trees", Index: treeIndex}}, d.Tiles...)
+		}},
+		{"unknown gid", func(d *SaveData) {
+			d.Tiles = append([]TileEdit{{Map: d.Map, Layer: "Trees", Index: treeIndex + 1, GID: 100000}}, d.Tiles...)
+		}},
+		{"unknown layer", func(d *SaveData) {
+			d.Tiles = append([]TileEdit{{Map: d.Map, Layer: "Roofs", Index: treeIndex + 1}}, d.Tiles...)
+		}},
+		{"index off the map", func(d *SaveData) {
+			d.Tiles = append([]TileEdit{{Map: d.Map, Layer: "Trees", Index: 50 * 40}}, d.Tiles...)
+		}},
+		{"negative index", func(d *SaveData) {
+			d.Tiles = append([]TileEdit{{Map: d.Map, Layer: "Trees", Index: -1}}, d.Tiles...)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.AddToInventory("wood", 3)
+			if err := w.SetTile("Trees", treeIndex, 0); err != nil {
+				t.Fatal(err)
+			}
+			w.Player.Pos.X += 2 * TileSize
+			w.Health, w.Hunger = 0.5, 0.3
+			idle(w, 5*ticksPerGameMinute)
+			before := w.Snapshot()
+
+			d := w.Snapshot()
+			tt.spoil(d)
+			if err := w.Restore(d); err == nil {
+				t.Fatal("spoilt save was restored")
+			}
+			if after := w.Snapshot(); !reflect.DeepEqual(after, before) {
+				t.Errorf("world changed by a failed restore:\n got %+v\nwant %+v", after, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"sort"
+)
+
+// TilePoint is a map position in tiles.
+type TilePoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (t TilePoint) pos() image.Point {
+	return image.Point{X: t.X * TileSize, Y: t.Y * TileSize}
+}
+
+// ScheduleEntry starts an activity at a time of day and lasts until the next
+// entry. Activities:
+//
+//	goto    walk to X, Y and wait there
+//	wander  stroll around at random
+//	home    walk to the NPC's door and leave the map until the next entry
+type ScheduleEntry struct {
+	At       string `json:"at"` // "HH:MM"
+	Activity string `json:"activity"`
+	X        int    `json:"x,omitempty"`
+	Y        int    `json:"y,omitempty"`
+
+	atMin int
+}
+
+// NPCDef is the data form of an NPC: its look, the conversation it starts,
+// the door it goes home through and its daily schedule. Where it stands when
+// a run starts comes from an npc object on one of the maps.
+type NPCDef struct {
+	Name     string          `json:"name"`
+	Sprite   string          `json:"sprite"`
+	Dialogue string          `json:"dialogue,omitempty"` // defaults to the NPC's name
+	Home     *TilePoint      `json:"home,omitempty"`     // defaults to the spawn tile
+	Schedule []ScheduleEntry `json:"schedule"`
+
+	// Set from the npc object placing the NPC, see npcSpawns
+	onMap  string
+	start  TilePoint
+	placed bool
+}
+
+// LoadNPCs reads NPC definitions and schedules from a JSON file.
+func LoadNPCs(path string) ([]*NPCDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		NPCs []*NPCDef `json:"npcs"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, def := range f.NPCs {
+		if def.Name == "" || seen[def.Name] {
+			return nil, fmt.Errorf("%s: missing or duplicate npc name %q", path, def.Name)
+		}
+		seen[def.Name] = true
+		for i := range def.Schedule {
+			e := &def.Schedule[i]
+			if e.atMin, err = parseClock(e.At); err != nil {
+				return nil, fmt.Errorf("%s: npc %q: %w", path, def.Name, err)
+			}
+			switch e.Activity {
+			case "goto", "wander", "home":
+			default:
+				return nil, fmt.Errorf("%s: npc %q: unknown activity %q", path, def.Name, e.Activity)
+			}
+		}
+		sort.SliceStable(def.Schedule, func(i, j int) bool { return def.Schedule[i].atMin < def.Schedule[j].atMin })
+	}
+	return f.NPCs, nil
+}
+
+func (d *NPCDef) home() TilePoint {
+	if d.Home == nil {
+		return d.start
+	}
+	return *d.Home
+}
+
+// Activity returns the schedule entry in effect at the given minute of the
+// day. Before the first entry the last one from the previous day still holds;
+// an NPC without a schedule wanders.
+func (d *NPCDef) Activity(minute int) ScheduleEntry {
+	if len(d.Schedule) == 0 {
+		return ScheduleEntry{Activity: "wander"}
+	}
+	cur := d.Schedule[len(d.Schedule)-1]
+	for _, e := range d.Schedule {
+		if e.atMin <= minute {
+			cur = e
+		}
+	}
+	return cur
+}
+
+// stepSchedule picks the next move for an idle NPC from its schedule.
+func (w *World) stepSchedule(npc *NPC) {
+	act := ScheduleEntry{Activity: "wander"}
+	if npc.def != nil {
+		act = npc.def.Activity(w.GameMinutes)
+	}
+	if npc.Away {
+		if act.Activity != "home" && w.npcCanStand(npc, npc.def.home().pos()) {
+			npc.Pos = npc.def.home().pos()
+			npc.Dir = 0
+			npc.Away = false
+		}
+		return
+	}
+	var dest image.Point
+	switch act.Activity {
+	case "goto":
+		dest = TilePoint{act.X, act.Y}.pos()
+	case "home":
+		dest = npc.def.home().pos()
+	default:
+		npc.moveTick++
+		if npc.moveTick > 30+w.rng.Intn(30) {
+			npc.moveTick = 0
+			w.npcRandomStep(npc)
+		}
+		return
+	}
+	if npc.Pos == dest {
+		if act.Activity == "home" {
+			npc.Away = true
+		}
+		return
+	}
+	// Walk with purpose: a step every few ticks instead of every second
+	npc.moveTick++
+	if npc.moveTick < 8 {
+		return
+	}
+	npc.moveTick = 0
+	if !w.npcFollowPath(npc, dest) {
+		w.npcRandomStep(npc)
+	}
+}
@@ -0,0 +1,101 @@
+package sim
+
+import (
+	"image"
+	"testing"
+)
+
+// npcNamed finds an NPC on the current map.
+func npcNamed(t *testing.T, w *World, name string) *NPC {
+	t.Helper()
+	for _, npc := range w.NPCs {
+		if npc.Name == name {
+			return npc
+		}
+	}
+	t.Fatalf("no NPC %s on %s", name, w.MapName)
+	return nil
+}
+
+func TestScheduleActivity(t *testing.T) {
+	w := newTestWorld(t)
+	kid := npcNamed(t, w, "Kid").def
+	tests := []struct {
+		at       string
+		activity string
+		x, y     int
+	}{
+		{"00:00", "home", 0, 0}, // last night's entry still holds
+		{"06:59", "home", 0, 0},
+		{"07:00", "wander", 0, 0},
+		{"11:59", "wander", 0, 0},
+		{"12:00", "goto", 30, 34},
+		{"12:59", "goto", 30, 34},
+		{"13:00", "wander", 0, 0},
+		{"19:30", "home", 0, 0},
+		{"23:59", "home", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			minute, err := parseClock(tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := kid.Activity(minute)
+			if e.Activity != tt.activity || e.X != tt.x || e.Y != tt.y {
+				t.Errorf("Activity = %s (%d, %d), want %s (%d, %d)", e.Activity, e.X, e.Y, tt.activity, tt.x, tt.y)
+			}
+		})
+	}
+
+	if e := (&NPCDef{}).Activity(600); e.Activity != "wander" {
+		t.Errorf("no schedule: Activity = %s, want wander", e.Activity)
+	}
+}
+
+func TestScheduleAway(t *testing.T) {
+	w := newTestWorld(t)
+	kid := npcNamed(t, w, "Kid")
+	if kid.Away {
+		t.Fatal("Kid away at 08:00")
+	}
+
+	// Standing at the door when it's time to go home
+	kid.Pos, kid.Moving = kid.def.home().pos(), false
+	w.GameMinutes = 19*60 + 30
+	w.Step(Input{})
+	if !kid.Away {
+		t.Fatal("Kid did not go in at 19:30")
+	}
+
+	// Nobody to talk to while away
+	idle(w, actionBlockTicks)
+	w.Player.Pos = kid.Pos.Sub(image.Point{Y: TileSize})
+	w.Player.Dir = 0
+	w.Step(Input{Action: true})
+	if w.Chatting {
+		t.Fatal("chatting with the Kid while away")
+	}
+	w.InventoryOpen = false
+
+	// Back out in the morning, once the door is clear
+	w.Player.Pos = w.playerSpawn()
+	w.GameMinutes = 7 * 60
+	w.Step(Input{})
+	if kid.Away || kid.Pos != kid.def.home().pos() {
+		t.Errorf("at 07:00: away %v at %v, want out at the door", kid.Away, kid.Pos)
+	}
+	talkTo(t, w, "Kid")
+}
+
+func TestScheduleSpawnAway(t *testing.T) {
+	w := newTestWorld(t)
+	w.GameMinutes = 21 * 60
+	w.SpawnNPCs()
+	for _, npc := range w.NPCs {
+		// The Alchemist stays out until 22:00
+		if want := npc.Name != "Alchemist"; npc.Away != want {
+			t.Errorf("%s away %v at 21:00, want %v", npc.Name, npc.Away, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Currency is the inventory item used to pay for goods.
+const Currency = "coin"
+
+// ShopItem is one line of a shop's price list. Buy is what the player pays,
+// Sell is what the shopkeeper pays for one; 0 means the item is not traded
+// in that direction. Quantity is how many the shop holds after a restock.
+type ShopItem struct {
+	Item     string `json:"item"`
+	Buy      int    `json:"buy,omitempty"`
+	Sell     int    `json:"sell,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+type ShopDef struct {
+	NPC       string     `json:"npc"`
+	RestockAt string     `json:"restockAt"` // "HH:MM" the shop refills every day
+	Stock     []ShopItem `json:"stock"`
+}
+
+// Shop is a shopkeeper's live stock.
+type Shop struct {
+	Def        ShopDef
+	Stock      map[string]int // items left to buy today
+	restockMin int
+}
+
+// LoadShops reads the shop definitions from a JSON file and returns fully
+// stocked shops keyed by NPC name.
+func LoadShops(path string) (map[string]*Shop, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Shops []ShopDef `json:"shops"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	shops := map[string]*Shop{}
+	for _, def := range f.Shops {
+		restockMin, err := parseClock(def.RestockAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: shop %q: %w", path, def.NPC, err)
+		}
+		shop := &Shop{Def: def, restockMin: restockMin}
+		shop.Restock()
+		shops[def.NPC] = shop
+	}
+	return shops, nil
+}
+
+// Restock fills every line back up to its daily quantity.
+func (s *Shop) Restock() {
+	s.Stock = map[string]int{}
+	for _, it := range s.Def.Stock {
+		s.Stock[it.Item] = it.Quantity
+	}
+}
+
+// Buyable lists the items the shop sells.
+func (s *Shop) Buyable() []ShopItem {
+	var items []ShopItem
+	for _, it := range s.Def.Stock {
+		if it.Buy > 0 {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// Sellable lists the items the shop buys from the player.
+func (s *Shop) Sellable() []ShopItem {
+	var items []ShopItem
+	for _, it := range s.Def.Stock {
+		if it.Sell > 0 {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// TradeLines is what the trade panel currently lists: the shop's goods while
+// buying or the items it buys while selling.
+func (w *World) TradeLines() []ShopItem {
+	if w.Trading == nil {
+		return nil
+	}
+	if w.TradeSelling {
+		return w.Trading.Sellable()
+	}
+	return w.Trading.Buyable()
+}
+
+// openShop starts trading with the shop of the NPC being talked to.
+func (w *World) openShop() {
+	if w.ChatNPC == nil {
+		return
+	}
+	if shop := w.Shops[w.ChatNPC.Name]; shop != nil {
+		w.Trading = shop
+		w.TradeChoice = 0
+		w.TradeSelling = false
+		w.TradeMsg = ""
+		w.lastInventory = w.Tick
+	}
+}
+
+// --- Trade window logic ---
+func (w *World) stepTrade(in Input) {
+	if w.Tick-w.lastInventory <= inputDelayTicks {
+		return
+	}
+	lines := w.TradeLines()
+	switch {
+	case in.Back:
+		w.Trading = nil
+		w.lastChatEnd = w.Tick
+	case in.Left || in.Right:
+		w.TradeSelling = !w.TradeSelling
+		w.TradeChoice = 0
+		w.TradeMsg = ""
+	case in.Up && len(lines) > 0:
+		w.TradeChoice = (w.TradeChoice + len(lines) - 1) % len(lines)
+	case in.Down && len(lines) > 0:
+		w.TradeChoice = (w.TradeChoice + 1) % len(lines)
+	case in.Action && w.TradeChoice < len(lines):
+		if w.TradeSelling {
+			w.TradeMsg = w.sell(lines[w.TradeChoice])
+		} else {
+			w.TradeMsg = w.buy(lines[w.TradeChoice])
+		}
+	default:
+		return
+	}
+	w.lastInventory = w.Tick
+}
+
+func (w *World) buy(it ShopItem) string {
+	switch {
+	case w.Trading.Stock[it.Item] <= 0:
+		return "Sold out until tomorrow."
+	case !w.HasItem(Currency, it.Buy):
+		return "Not enough coins."
+	case !w.CanAdd(it.Item, 1):
+		return "Your bag is full."
+	}
+	w.RemoveItem(Currency, it.Buy)
+	w.AddToInventory(it.Item, 1)
+	w.Trading.Stock[it.Item]--
+	return fmt.Sprintf("Bought %s for %d coins.", w.ItemName(it.Item), it.Buy)
+}
+
+func (w *World) sell(it ShopItem) string {
+	if !w.HasItem(it.Item, 1) {
+		return "You have no " + w.ItemName(it.Item) + "."
+	}
+	if !w.CanAdd(Currency, it.Sell) {
+		return "Your coin purse is full."
+	}
+	w.RemoveItem(it.Item, 1)
+	w.AddToInventory(Currency, it.Sell)
+	return fmt.Sprintf("Sold %s for %d coins.", w.ItemName(it.Item), it.Sell)
+}
+
+// restockShops refills every shop whose restock time has come.
+func (w *World) restockShops() {
+	for _, shop := range w.Shops {
+		if shop.restockMin == w.GameMinutes {
+			shop.Restock()
+		}
+	}
+}
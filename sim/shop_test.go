@@ -0,0 +1,114 @@
+package sim
+
+import "testing"
+
+// shopLine finds an item on the Merchant's price list.
+func shopLine(t *testing.T, w *World, item string) ShopItem {
+	t.Helper()
+	for _, it := range w.Trading.Def.Stock {
+		if it.Item == item {
+			return it
+		}
+	}
+	t.Fatalf("Merchant doesn't trade %s", item)
+	return ShopItem{}
+}
+
+func TestShopBuy(t *testing.T) {
+	tests := []struct {
+		name  string
+		coins int
+		stock int  // bread left in the shop
+		full  bool // every other slot holds a tool
+		msg   string
+		bread int
+		purse int
+	}{
+		{"bought", 12, 4, false, "Bought Bread for 5 coins.", 1, 7},
+		{"exact coins", 5, 1, false, "Bought Bread for 5 coins.", 1, 0},
+		{"sold out", 12, 0, false, "Sold out until tomorrow.", 0, 12},
+		{"too few coins", 4, 4, false, "Not enough coins.", 0, 4},
+		{"bag full", 12, 4, true, "Your bag is full.", 0, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.Trading = w.Shops["Merchant"]
+			w.Trading.Stock["bread"] = tt.stock
+			w.AddToInventory(Currency, tt.coins)
+			if tt.full {
+				w.AddToInventory("hoe", 63)
+			}
+			if msg := w.buy(shopLine(t, w, "bread")); msg != tt.msg {
+				t.Errorf("buy says %q, want %q", msg, tt.msg)
+			}
+			if n := w.CountItem("bread"); n != tt.bread {
+				t.Errorf("%d bread, want %d", n, tt.bread)
+			}
+			if n := w.CountItem(Currency); n != tt.purse {
+				t.Errorf("%d coins left, want %d", n, tt.purse)
+			}
+			if left := tt.stock - tt.bread; w.Trading.Stock["bread"] != left {
+				t.Errorf("shop has %d bread, want %d", w.Trading.Stock["bread"], left)
+			}
+		})
+	}
+}
+
+func TestShopSell(t *testing.T) {
+	tests := []struct {
+		name  string
+		item  string
+		have  int
+		coins int // in the purse before selling
+		msg   string
+		left  int
+		paid  int
+	}{
+		{"fish", "fish", 2, 0, "Sold Fish for 2 coins.", 1, 2},
+		{"salmon", "salmon", 1, 10, "Sold Salmon for 8 coins.", 0, 18},
+		{"none", "trout", 0, 0, "You have no Trout.", 0, 0},
+		{"purse full", "salmon", 1, 99, "Your coin purse is full.", 1, 99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.Trading = w.Shops["Merchant"]
+			w.AddToInventory(tt.item, tt.have)
+			w.AddToInventory(Currency, tt.coins)
+			if tt.coins == 99 {
+				// Leave no room for a second stack of coins
+				w.AddToInventory("hoe", 62)
+			}
+			if msg := w.sell(shopLine(t, w, tt.item)); msg != tt.msg {
+				t.Errorf("sell says %q, want %q", msg, tt.msg)
+			}
+			if n := w.CountItem(tt.item); n != tt.left {
+				t.Errorf("%d %s left, want %d", n, tt.item, tt.left)
+			}
+			if n := w.CountItem(Currency); n != tt.paid {
+				t.Errorf("%d coins, want %d", n, tt.paid)
+			}
+		})
+	}
+}
+
+func TestShopRestock(t *testing.T) {
+	w := newTestWorld(t)
+	shop := w.Shops["Merchant"]
+	shop.Stock["bread"] = 0
+	shop.Stock["potion"] = 1
+
+	w.GameMinutes = 5*60 + 58
+	idle(w, ticksPerGameMinute)
+	if shop.Stock["bread"] != 0 {
+		t.Fatalf("restocked at %d, before 06:00", w.GameMinutes)
+	}
+	idle(w, ticksPerGameMinute)
+	if w.GameMinutes != 6*60 {
+		t.Fatalf("clock at %d, want 06:00", w.GameMinutes)
+	}
+	if shop.Stock["bread"] != 4 || shop.Stock["potion"] != 2 {
+		t.Errorf("after 06:00: %d bread, %d potions, want 4 and 2", shop.Stock["bread"], shop.Stock["potion"])
+	}
+}
@@ -0,0 +1,589 @@
+package sim
+
+import (
+	"image"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// Step advances the world by one fixed tick using the given input snapshot.
+func (w *World) Step(in Input) {
+	w.Tick++
+	w.generateAround()
+	if w.GameOver {
+		// Restart game on any key press or mouse click
+		if in.Restart && w.Tick-w.diedAt > actionBlockTicks {
+			w.Reset()
+		}
+		return
+	}
+
+	w.stepNPCs()
+	w.stepClock()
+	if w.GameOver {
+		return
+	}
+
+	if w.Chatting {
+		w.stepChat(in)
+		return // Don't allow movement while chatting
+	}
+	if w.Trading != nil {
+		w.stepTrade(in)
+		return
+	}
+	if w.Fishing != nil {
+		w.stepFishing(in)
+		return
+	}
+	if w.stepBuild(in) {
+		w.stepPlayer(Input{Left: in.Left, Right: in.Right, Up: in.Up, Down: in.Down})
+		return
+	}
+	if w.stepInventory(in) {
+		return
+	}
+	w.stepPlayer(in)
+}
+
+// --- NPC movement and animation logic ---
+func (w *World) stepNPCs() {
+	for _, npc := range w.NPCs {
+		// While chatting or trading NPCs start no new steps, but one in
+		// progress finishes so they stop on the tile grid
+		if (w.Chatting || w.Trading != nil) && !npc.Moving {
+			continue
+		}
+		if npc.Away {
+			w.stepSchedule(npc)
+			continue
+		}
+		if !npc.Moving {
+			w.stepSchedule(npc)
+		} else {
+			// Move smoothly toward target
+			step := 2
+			dx := npc.target.X - npc.Pos.X
+			dy := npc.target.Y - npc.Pos.Y
+			if dx != 0 {
+				if abs(dx) < step {
+					npc.Pos.X = npc.target.X
+				} else {
+					npc.Pos.X += step * sign(dx)
+				}
+			}
+			if dy != 0 {
+				if abs(dy) < step {
+					npc.Pos.Y = npc.target.Y
+				} else {
+					npc.Pos.Y += step * sign(dy)
+				}
+			}
+			if npc.Pos == npc.target {
+				npc.Moving = false
+			}
+		}
+		// Animate NPC sprite
+		npc.AnimTick++
+		if npc.AnimTick > 15 {
+			npc.Anim = (npc.Anim + 1) % 4
+			npc.AnimTick = 0
+		}
+	}
+}
+
+// npcSteps are the four single-tile moves an NPC can make.
+var npcSteps = []struct{ dx, dy, dir int }{
+	{0, -TileSize, 3}, // up
+	{0, TileSize, 0},  // down
+	{-TileSize, 0, 2}, // left
+	{TileSize, 0, 1},  // right
+}
+
+// npcCanStand reports whether npc fits at pos without leaving the map or
+// overlapping a wall, the player or another NPC.
+func (w *World) npcCanStand(npc *NPC, pos image.Point) bool {
+	// Check map bounds
+	if pos.X < 0 || pos.Y < 0 || pos.X > w.Map.Width*TileSize-TileSize/4 || pos.Y > w.Map.Height*TileSize-TileSize/4 {
+		return false
+	}
+	if w.blockedAt(pos) {
+		return false
+	}
+	// Check collision with all other characters (player and NPCs)
+	npcRect := image.Rect(pos.X, pos.Y, pos.X+TileSize, pos.Y+TileSize)
+	p := w.Player.Pos
+	playerRect := image.Rect(p.X, p.Y, p.X+TileSize, p.Y+TileSize)
+	if npcRect.Overlaps(playerRect) {
+		return false
+	}
+	for _, other := range w.NPCs {
+		if other == npc || other.Away {
+			continue
+		}
+		otherRect := image.Rect(other.Pos.X, other.Pos.Y, other.Pos.X+TileSize, other.Pos.Y+TileSize)
+		if npcRect.Overlaps(otherRect) {
+			return false
+		}
+	}
+	return true
+}
+
+// npcTryStep starts npc walking one tile in the given direction if it can.
+func (w *World) npcTryStep(npc *NPC, dx, dy, dir int) bool {
+	next := image.Point{X: npc.Pos.X + dx, Y: npc.Pos.Y + dy}
+	if !w.npcCanStand(npc, next) {
+		return false
+	}
+	npc.target = next
+	npc.Dir = dir
+	npc.Moving = true
+	return true
+}
+
+// npcRandomStep moves npc one tile in a random open direction.
+func (w *World) npcRandomStep(npc *NPC) {
+	dirs := append([]struct{ dx, dy, dir int }{}, npcSteps...)
+	w.rng.Shuffle(len(dirs), func(i, j int) { dirs[i], dirs[j] = dirs[j], dirs[i] })
+	for _, d := range dirs {
+		if w.npcTryStep(npc, d.dx, d.dy, d.dir) {
+			return
+		}
+	}
+}
+
+// npcFollowPath moves npc one tile along an A* route to dest, planning a new
+// route when it has none or the next tile is taken. It reports false when
+// dest cannot be reached right now.
+func (w *World) npcFollowPath(npc *NPC, dest image.Point) bool {
+	goal := tileAt(dest)
+	if len(npc.path) == 0 || npc.path[len(npc.path)-1] != goal {
+		npc.path = w.FindPath(tileAt(npc.Pos), goal, nil)
+	}
+	for attempt := 0; attempt < 2 && len(npc.path) > 0; attempt++ {
+		cur, next := tileAt(npc.Pos), npc.path[0]
+		for _, d := range npcSteps {
+			if cur.X+d.dx/TileSize == next.X && cur.Y+d.dy/TileSize == next.Y && w.npcTryStep(npc, d.dx, d.dy, d.dir) {
+				npc.path = npc.path[1:]
+				return true
+			}
+		}
+		// Someone is in the way: route around every character
+		npc.path = w.FindPath(tileAt(npc.Pos), goal, w.occupiedBy(npc))
+	}
+	return false
+}
+
+// --- In-game time and bar drain logic ---
+func (w *World) stepClock() {
+	w.minuteTicks++
+	if w.minuteTicks < ticksPerGameMinute {
+		return
+	}
+	w.minuteTicks = 0
+	w.GameMinutes = (w.GameMinutes + 1) % MinutesPerDay
+	w.restockShops()
+	w.stepCrafting()
+	w.stepRegrowth()
+	w.stepFarm()
+	w.stepFires()
+
+	w.minutesSinceSave++
+	if w.AutosaveEvery > 0 && w.minutesSinceSave >= w.AutosaveEvery {
+		w.minutesSinceSave = 0
+		w.autosaveDue = true
+	}
+
+	// Drain 50% from hunger/social every 24 in-game hours, but smoothly
+	// That is, every in-game minute, drain (0.5 / 1440) from each
+	drainPerMinute := 0.5 / 1440.0
+	w.Hunger -= drainPerMinute
+	w.Social -= drainPerMinute * w.socialDrain()
+	if w.Hunger < 0 {
+		w.Hunger = 0
+	}
+	if w.Social < 0 {
+		w.Social = 0
+	}
+	w.Stats.MinutesSurvived++
+	// After the count moves on, so the weather matches what Restore works
+	// out from a save written this minute
+	w.stepWeather()
+	w.stepHealth()
+}
+
+// stepHealth runs once per in-game minute. Health drains while hunger or
+// social is empty and slowly regenerates while both are high.
+func (w *World) stepHealth() {
+	const (
+		drainPerEmptyBar = 1.0 / (8 * 60) // one empty bar kills in 8 in-game hours
+		regenPerMinute   = 0.5 / 1440.0
+		regenThreshold   = 0.7
+	)
+	starving := w.Hunger <= 0
+	lonely := w.Social <= 0
+	switch {
+	case starving || lonely:
+		if starving {
+			w.Health -= drainPerEmptyBar
+		}
+		if lonely {
+			w.Health -= drainPerEmptyBar
+		}
+	case w.Hunger >= regenThreshold && w.Social >= regenThreshold:
+		w.Health += regenPerMinute
+		if w.Health > 1.0 {
+			w.Health = 1.0
+		}
+	}
+	if w.Health > 0 {
+		return
+	}
+	w.Health = 0
+	cause := "starvation"
+	switch {
+	case starving && lonely:
+		cause = "starvation and loneliness"
+	case lonely:
+		cause = "loneliness"
+	}
+	w.die(cause)
+}
+
+// die ends the run and records a summary for the game over screen.
+func (w *World) die(cause string) {
+	w.GameOver = true
+	w.Chatting = false
+	w.ChatNPC = nil
+	w.ConvNode = nil
+	w.InventoryOpen = false
+	w.Trading = nil
+	w.Fishing = nil
+	w.Building = nil
+	w.Death = &DeathSummary{Cause: cause, Stats: w.Stats}
+	w.diedAt = w.Tick
+}
+
+// --- NPC interaction logic ---
+func (w *World) stepChat(in Input) {
+	choices := w.VisibleChoices()
+	if w.ChatChoice >= len(choices) {
+		w.ChatChoice = 0
+	}
+	// Only allow input if enough time has passed since last choice
+	if w.Tick-w.lastChoice <= inputDelayTicks {
+		return
+	}
+	switch {
+	case in.Up:
+		w.ChatChoice--
+		if w.ChatChoice < 0 {
+			w.ChatChoice = len(choices) - 1
+		}
+		w.lastChoice = w.Tick
+	case in.Down:
+		w.ChatChoice++
+		if w.ChatChoice >= len(choices) {
+			w.ChatChoice = 0
+		}
+		w.lastChoice = w.Tick
+	case in.Action:
+		choice := choices[w.ChatChoice]
+		ok := true
+		for _, e := range choice.Effects {
+			if ok = w.Apply(e); !ok {
+				break
+			}
+		}
+		// A failed effect ends the chat; the next node may assume it worked
+		if !ok || choice.Next == nil {
+			w.endChat()
+		} else {
+			w.ConvNode = choice.Next
+			w.ChatChoice = 0
+		}
+		w.lastChoice = w.Tick
+	}
+}
+
+func (w *World) endChat() {
+	w.Chatting = false
+	w.ChatNPC = nil
+	w.ConvNode = nil
+	w.target = nil
+	w.lastChatEnd = w.Tick
+	// Social bar +10% when talking to NPC
+	w.Social += 0.10
+	if w.Social > 1.0 {
+		w.Social = 1.0
+	}
+}
+
+// stepInventory handles opening, closing and acting in the inventory. It
+// reports whether the rest of the tick should be skipped.
+func (w *World) stepInventory(in Input) bool {
+	ready := w.Tick-w.lastInventory > inputDelayTicks
+
+	// Prevent inventory open for 1s after tree cut or fishing
+	blockInventory := w.Tick-w.lastChatEnd < actionBlockTicks
+
+	// --- Inventory open/close logic with delay and block after action ---
+	if in.Action && !w.InventoryOpen && ready && !blockInventory {
+		// Only open inventory if not interacting with NPC, water, trees, or doors
+		if !w.facingInteractable() {
+			w.InventoryOpen = true
+			w.lastInventory = w.Tick
+			return true
+		}
+	}
+
+	// Inventory interaction: close with space, pick and craft a recipe, or eat
+	if !w.InventoryOpen {
+		return false
+	}
+	// Only allow input if enough time has passed since last inventory action
+	if !ready {
+		return true
+	}
+	craftable := w.CraftableRecipes()
+	if w.CraftChoice >= len(craftable) {
+		w.CraftChoice = 0
+	}
+	switch {
+	case in.Action:
+		w.InventoryOpen = false
+		w.lastInventory = w.Tick
+	case in.Up && len(craftable) > 0:
+		w.CraftChoice = (w.CraftChoice + len(craftable) - 1) % len(craftable)
+		w.lastInventory = w.Tick
+	case in.Down && len(craftable) > 0:
+		w.CraftChoice = (w.CraftChoice + 1) % len(craftable)
+		w.lastInventory = w.Tick
+	case in.Craft && len(craftable) > 0:
+		if w.startCraft(craftable[w.CraftChoice]) {
+			// Block inventory open for 1s after crafting
+			w.lastChatEnd = w.Tick
+		}
+		w.lastInventory = w.Tick
+	case in.Eat:
+		if food := w.FirstFood(); food != "" {
+			w.Eat(food)
+		}
+		w.lastInventory = w.Tick
+	}
+	return true
+}
+
+// Player movement logic
+func (w *World) stepPlayer(in Input) {
+	const moveSpeed = 1
+
+	p := &w.Player
+	// Tiles can slow the player down or speed them up (walk_speed); the
+	// fraction of a pixel left over carries into the next tick
+	p.moveAcc += moveSpeed * w.walkSpeedAt(w.tileIndex(p.Pos))
+	speed := int(p.moveAcc)
+	p.moveAcc -= float64(speed)
+	newPos := p.Pos
+	p.Moving = false
+	if in.Left {
+		newPos.X -= speed
+		p.Dir = 1 // left
+		p.Moving = true
+	}
+	if in.Right {
+		newPos.X += speed
+		p.Dir = 2 // right
+		p.Moving = true
+	}
+	if in.Up {
+		newPos.Y -= speed
+		p.Dir = 3 // up
+		p.Moving = true
+	}
+	if in.Down {
+		newPos.Y += speed
+		p.Dir = 0 // down
+		p.Moving = true
+	}
+
+	// Clamp intended position to map bounds
+	maxX := w.Map.Width*TileSize - TileSize
+	maxY := w.Map.Height*TileSize - TileSize
+	if newPos.X < 0 {
+		newPos.X = 0
+	}
+	if newPos.Y < 0 {
+		newPos.Y = 0
+	}
+	if newPos.X > maxX {
+		newPos.X = maxX
+	}
+	if newPos.Y > maxY {
+		newPos.Y = maxY
+	}
+
+	blocked := w.blockedAt(newPos)
+	// Collision with all characters (player can't walk through NPCs or other players)
+	playerRect := image.Rect(newPos.X, newPos.Y, newPos.X+TileSize, newPos.Y+TileSize)
+	for _, npc := range w.NPCs {
+		if npc.Away {
+			continue
+		}
+		npcRect := image.Rect(npc.Pos.X, npc.Pos.Y, npc.Pos.X+TileSize, npc.Pos.Y+TileSize)
+		if playerRect.Overlaps(npcRect) {
+			blocked = true
+			break
+		}
+	}
+	if !blocked {
+		p.Pos = newPos
+	}
+	w.collectPickups()
+	w.stepTriggers()
+
+	// Animation: advance frame if moving, else reset to stand
+	if p.Moving {
+		p.AnimTick++
+		if p.AnimTick > 10 {
+			p.Anim = (p.Anim + 1) % 4 // 4 frames: 0-3
+			p.AnimTick = 0
+		}
+	} else {
+		p.Anim = 0
+		p.AnimTick = 0
+	}
+
+	// Check for NPC or layer interaction
+	// Prevent chat if less than 1 second since last chat ended
+	if in.Action && w.Tick-w.lastChatEnd >= actionBlockTicks {
+		w.interact()
+	}
+	if in.Use && w.Tick-w.lastUse > inputDelayTicks {
+		w.lastUse = w.Tick
+		w.farm()
+	}
+}
+
+func (w *World) interact() {
+	// --- NPC interaction ---
+	for _, npc := range w.NPCs {
+		if isFacingNPC(w, npc) {
+			conv := w.Conversations[npc.dialogue()]
+			if conv == nil {
+				continue
+			}
+			w.lastChoice = w.Tick
+			w.Chatting = true
+			w.ChatNPC = npc
+			w.ChatChoice = 0
+			// Face each other
+			p := &w.Player
+			if p.Pos.X < npc.Pos.X {
+				p.Dir = 2   // right
+				npc.Dir = 1 // npc faces left
+			} else if p.Pos.X > npc.Pos.X {
+				p.Dir = 1   // left
+				npc.Dir = 2 // npc faces right
+			} else if p.Pos.Y < npc.Pos.Y {
+				p.Dir = 0   // down
+				npc.Dir = 3 // npc faces up
+			} else if p.Pos.Y > npc.Pos.Y {
+				p.Dir = 3   // up
+				npc.Dir = 0 // npc faces down
+			}
+			// Start conversation
+			w.ConvNode = conv
+			return
+		}
+	}
+
+	// --- Doors and other portals to another map ---
+	interactX, interactY := w.interactTile()
+	if p, ok := w.portalAt(TilePoint{interactX, interactY}); ok {
+		if w.EnterMap(p.TargetMap, p.TargetSpawn) == nil {
+			w.lastChatEnd = w.Tick // don't go straight back through
+		}
+		return
+	}
+
+	// --- Tile interaction (fishing, chopping, ...) ---
+	if interactX < 0 || interactX >= w.Map.Width || interactY < 0 || interactY >= w.Map.Height {
+		return
+	}
+	tileIdx := interactY*w.Map.Width + interactX
+	layer, kind := w.interactionAt(tileIdx)
+	if conv := w.Conversations[kind]; conv != nil && kind != "door" {
+		w.startAction(conv, &interactTarget{layer: layer.Name, index: tileIdx})
+	}
+}
+
+// startAction opens an NPC-less dialogue such as fishing or chopping.
+func (w *World) startAction(node *ConversationNode, target *interactTarget) {
+	w.target = target
+	w.Chatting = true
+	w.ChatNPC = nil
+	w.ChatChoice = 0
+	w.ConvNode = node
+	w.lastChoice = w.Tick
+}
+
+// interactTile returns the tile the player is facing.
+func (w *World) interactTile() (int, int) {
+	centerX := w.Player.Pos.X + TileSize/2
+	centerY := w.Player.Pos.Y + TileSize/2
+	interactX, interactY := centerX/TileSize, centerY/TileSize
+	switch w.Player.Dir {
+	case 0: // down
+		interactY++
+	case 1: // left
+		interactX--
+	case 2: // right
+		interactX++
+	case 3: // up
+		interactY--
+	}
+	return interactX, interactY
+}
+
+// facingInteractable reports whether the player is next to an NPC or facing
+// a portal or a tile with an interaction.
+func (w *World) facingInteractable() bool {
+	for _, npc := range w.NPCs {
+		if isFacingNPC(w, npc) {
+			return true
+		}
+	}
+	interactX, interactY := w.interactTile()
+	if _, ok := w.portalAt(TilePoint{interactX, interactY}); ok {
+		return true
+	}
+	if interactX < 0 || interactX >= w.Map.Width || interactY < 0 || interactY >= w.Map.Height {
+		return false
+	}
+	_, kind := w.interactionAt(interactY*w.Map.Width + interactX)
+	return kind != ""
+}
+
+// blockedAt checks whether the tile under the centre of a character at pos
+// collides.
+func (w *World) blockedAt(pos image.Point) bool {
+	idx := w.tileIndex(pos)
+	return idx >= 0 && w.collidesAt(idx)
+}
+
+// tileIndex is the layer index of the tile under the centre of a character at
+// pos, or -1 off the map.
+func (w *World) tileIndex(pos image.Point) int {
+	tileX := (pos.X + TileSize/2) / TileSize
+	tileY := (pos.Y + TileSize/2) / TileSize
+	if tileX < 0 || tileX >= w.Map.Width || tileY < 0 || tileY >= w.Map.Height {
+		return -1
+	}
+	return tileY*w.Map.Width + tileX
+}
+
+func hasTile(layer *tiled.Layer, idx int) bool {
+	tile := layer.Tiles[idx]
+	return tile != nil && tile.Tileset != nil
+}
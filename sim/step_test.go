@@ -0,0 +1,290 @@
+package sim
+
+import (
+	"image"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+const drainPerMinute = 0.5 / 1440.0
+
+// newTestWorld builds a world from the shipped assets the way main does,
+// with a fixed seed.
+func newTestWorld(t *testing.T) *World {
+	t.Helper()
+	load := func(name string) (*tiled.Map, error) {
+		return tiled.LoadFile(filepath.Join("..", "assets", name+".tmx"))
+	}
+	m, err := load("jons_first_map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorld("jons_first_map", m, 1)
+	w.LoadMap = load
+	asset := func(name string) string { return filepath.Join("..", "assets", name) }
+	if w.NPCDefs, err = LoadNPCs(asset("npcs.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Items, err = LoadItems(asset("items.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Conversations, err = LoadConversations(asset("dialogue")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Shops, err = LoadShops(asset("shops.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Recipes, err = LoadRecipes(asset("recipes.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Resources, err = LoadResources(asset("resources.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.LootTables, err = LoadLootTables(asset("loot.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Farm, err = LoadFarm(asset("farm.json")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Buildables, err = LoadBuildables(asset("buildables.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	w.Reset()
+	return w
+}
+
+// idle steps the world n ticks with no keys held.
+func idle(w *World, n int) {
+	for i := 0; i < n; i++ {
+		w.Step(Input{})
+	}
+}
+
+// press waits out the key repeat delay and steps once with in held.
+func press(w *World, in Input) {
+	idle(w, inputDelayTicks)
+	w.Step(in)
+}
+
+// talkTo stands the player just above the named NPC and presses action.
+func talkTo(t *testing.T, w *World, name string) *NPC {
+	t.Helper()
+	idle(w, actionBlockTicks)
+	for _, npc := range w.NPCs {
+		if npc.Name != name {
+			continue
+		}
+		w.Player.Pos = npc.Pos.Sub(image.Point{Y: TileSize})
+		w.Player.Dir = 0
+		w.Step(Input{Action: true})
+		if !w.Chatting || w.ChatNPC != npc {
+			t.Fatalf("talking to %s: chatting %v with %v", name, w.Chatting, w.ChatNPC)
+		}
+		return npc
+	}
+	t.Fatalf("no NPC %s on %s", name, w.MapName)
+	return nil
+}
+
+func TestStepClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticks   int
+		minutes int
+	}{
+		{"no tick", 0, 0},
+		{"under a minute", ticksPerGameMinute - 1, 0},
+		{"one minute", ticksPerGameMinute, 1},
+		{"one hour", 60 * ticksPerGameMinute, 60},
+		{"one day", MinutesPerDay * ticksPerGameMinute, MinutesPerDay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			idle(w, tt.ticks)
+			if want := (8*60 + tt.minutes) % MinutesPerDay; w.GameMinutes != want {
+				t.Errorf("GameMinutes = %d, want %d", w.GameMinutes, want)
+			}
+			if w.Stats.MinutesSurvived != tt.minutes {
+				t.Errorf("MinutesSurvived = %d, want %d", w.Stats.MinutesSurvived, tt.minutes)
+			}
+			drained := float64(tt.minutes) * drainPerMinute
+			if want := 1 - drained; math.Abs(w.Hunger-want) > 1e-9 {
+				t.Errorf("Hunger = %v, want %v", w.Hunger, want)
+			}
+			// Storms drain social faster, never slower
+			if w.Social > 1-drained+1e-9 || w.Social < 1-drained*(1+stormLonely)-1e-9 {
+				t.Errorf("Social = %v, want about %v", w.Social, 1-drained)
+			}
+		})
+	}
+}
+
+func TestStepDeath(t *testing.T) {
+	tests := []struct {
+		name           string
+		hunger, social float64
+		cause          string
+	}{
+		{"starving", 0, 1, "starvation"},
+		{"lonely", 1, 0, "loneliness"},
+		{"both", 0, 0, "starvation and loneliness"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			w.Hunger, w.Social = tt.hunger, tt.social
+			w.Health = 1.5 / (8 * 60) // dies within two minutes
+			// The clock runs on with the trade window open
+			w.Trading = w.Shops["Merchant"]
+			for i := 0; i < 2*ticksPerGameMinute && !w.GameOver; i++ {
+				w.Step(Input{})
+			}
+			if !w.GameOver || w.Death == nil {
+				t.Fatalf("still alive with health %v", w.Health)
+			}
+			if w.Death.Cause != tt.cause {
+				t.Errorf("cause = %q, want %q", w.Death.Cause, tt.cause)
+			}
+			if w.Trading != nil {
+				t.Error("trade window still open over the game over screen")
+			}
+
+			// Restart is ignored briefly, then starts a fresh run
+			w.Step(Input{Restart: true})
+			if !w.GameOver {
+				t.Fatal("restarted straight after dying")
+			}
+			for i := 0; i < actionBlockTicks && w.GameOver; i++ {
+				w.Step(Input{Restart: true})
+			}
+			if w.GameOver || w.Health != 1 || w.Hunger != 1 || w.Social != 1 {
+				t.Errorf("after restart: game over %v, bars %v %v %v", w.GameOver, w.Health, w.Hunger, w.Social)
+			}
+		})
+	}
+}
+
+func TestStepChat(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string // in the inventory before talking
+		keys  []Input  // pressed once the chat is open
+		node  string   // node shown afterwards, "" when the chat ended
+		flag  string   // flag set by a choice
+	}{
+		{"opens", nil, nil, "root", ""},
+		{"first choice", nil, []Input{{Action: true}}, "joke", ""},
+		{"down", nil, []Input{{Down: true}, {Action: true}}, "favorite", ""},
+		{"back to root", nil, []Input{{Action: true}, {Up: true}, {Up: true}, {Action: true}}, "root", ""},
+		{"up wraps to goodbye", nil, []Input{{Up: true}, {Action: true}}, "", ""},
+		{"hidden choice", nil, []Input{{Down: true}, {Down: true}, {Down: true}, {Action: true}}, "", ""},
+		{"shown with item", []string{"cooked_fish"}, []Input{{Down: true}, {Down: true}, {Down: true}, {Action: true}}, "fed", "fed_kid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			for _, item := range tt.items {
+				w.AddToInventory(item, 1)
+			}
+			w.Social = 0.5
+			npc := talkTo(t, w, "Kid")
+			if w.ConvNode != w.Conversations[npc.dialogue()] {
+				t.Fatalf("chat opened on %q, want the Kid's root", w.ConvNode.ID)
+			}
+			for _, in := range tt.keys {
+				press(w, in)
+			}
+			switch {
+			case tt.node == "":
+				if w.Chatting {
+					t.Fatalf("still chatting on %q", w.ConvNode.ID)
+				}
+				if w.Social < 0.59 {
+					t.Errorf("Social = %v after the chat, want +0.1", w.Social)
+				}
+			case !w.Chatting:
+				t.Fatalf("chat ended, want node %q", tt.node)
+			case w.ConvNode.ID != tt.node:
+				t.Errorf("node = %q, want %q", w.ConvNode.ID, tt.node)
+			}
+			if tt.flag != "" && !w.Flags[tt.flag] {
+				t.Errorf("flag %q not set", tt.flag)
+			}
+			for _, item := range tt.items {
+				if tt.flag != "" && w.HasItem(item, 1) {
+					t.Errorf("%s was not given away", item)
+				}
+			}
+		})
+	}
+}
+
+func TestStepEat(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   string
+		count  int
+		hunger float64
+		want   float64
+		left   int
+	}{
+		{"cooked fish", "cooked_fish", 2, 0.5, 0.6, 1},
+		{"bread", "bread", 1, 0.2, 0.35, 0},
+		{"capped", "cooked_salmon", 1, 0.9, 1, 0},
+		{"not food", "wood", 3, 0.5, 0.5, 3},
+		{"nothing", "", 0, 0.5, 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			if tt.item != "" {
+				w.AddToInventory(tt.item, tt.count)
+			}
+			idle(w, actionBlockTicks)
+			w.Hunger = tt.hunger
+			w.Step(Input{Action: true})
+			if !w.InventoryOpen {
+				t.Fatal("inventory did not open")
+			}
+			press(w, Input{Eat: true})
+			// A few minutes of drain pass between the key presses
+			if math.Abs(w.Hunger-tt.want) > 10*drainPerMinute {
+				t.Errorf("Hunger = %v, want %v", w.Hunger, tt.want)
+			}
+			if tt.item != "" && w.CountItem(tt.item) != tt.left {
+				t.Errorf("%d %s left, want %d", w.CountItem(tt.item), tt.item, tt.left)
+			}
+			if !w.InventoryOpen {
+				t.Error("eating closed the inventory")
+			}
+		})
+	}
+}
+
+func TestStepBuildOverInventory(t *testing.T) {
+	w := newTestWorld(t)
+	idle(w, actionBlockTicks)
+	w.Step(Input{Action: true})
+	if !w.InventoryOpen {
+		t.Fatal("inventory did not open")
+	}
+	press(w, Input{Build: true})
+	if w.Building != nil {
+		t.Fatal("build mode opened over the inventory")
+	}
+	press(w, Input{Action: true})
+	if w.InventoryOpen {
+		t.Fatal("space did not close the inventory")
+	}
+	press(w, Input{Build: true})
+	if w.Building == nil {
+		t.Error("build mode did not open once the inventory closed")
+	}
+}
@@ -0,0 +1,195 @@
+package sim
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// TileEdit records a map tile changed at runtime so saves can replay it on
+// top of the pristine TMX. GID 0 means the tile was removed.
+type TileEdit struct {
+	Map   string `json:"map"`
+	Layer string `json:"layer"`
+	Index int    `json:"index"`
+	GID   uint32 `json:"gid"`
+}
+
+type tileKey struct {
+	mapName string
+	layer   string
+	index   int
+}
+
+// tileRef is a tile position on a named map, whatever the layer.
+type tileRef struct {
+	mapName string
+	index   int
+}
+
+// SetTile replaces a tile on the named layer of the current map and
+// remembers the change. It fails, changing nothing, for a layer the map
+// lacks, an index off the map or a GID no tileset has.
+func (w *World) SetTile(layerName string, idx int, gid uint32) error {
+	return w.setTileOn(w.MapName, layerName, idx, gid)
+}
+
+func (w *World) setTileOn(mapName, layerName string, idx int, gid uint32) error {
+	m, err := w.mapByName(mapName)
+	if err != nil {
+		return err
+	}
+	layer, tile, err := editTile(m, layerName, idx, gid)
+	if err != nil {
+		return err
+	}
+	key := tileKey{mapName, layerName, idx}
+	if w.tileEdits == nil {
+		w.tileEdits = map[tileKey]uint32{}
+		w.tileOrig = map[tileKey]*tiled.LayerTile{}
+	}
+	if _, seen := w.tileOrig[key]; !seen {
+		w.tileOrig[key] = layer.Tiles[idx]
+	}
+	layer.Tiles[idx] = tile
+	w.tileEdits[key] = gid
+	w.tileChanged(m, layer, idx)
+	return nil
+}
+
+// editTile checks that m has a tile idx on the named layer that can be set
+// to gid, and returns the layer with the new tile.
+func editTile(m *tiled.Map, layerName string, idx int, gid uint32) (*tiled.Layer, *tiled.LayerTile, error) {
+	layer := mapLayer(m, layerName)
+	if layer == nil {
+		return nil, nil, fmt.Errorf("no layer %q", layerName)
+	}
+	if idx < 0 || idx >= len(layer.Tiles) {
+		return nil, nil, fmt.Errorf("tile %d is off the %dx%d map", idx, m.Width, m.Height)
+	}
+	tile, err := gidTile(m, gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return layer, tile, nil
+}
+
+// gidTile looks up the tile of a GID on m. Unlike TileGIDToTile it refuses
+// GIDs past the last tile of a tileset image, and for a collection of
+// images, whose IDs can have gaps, GIDs of tiles it doesn't list.
+func gidTile(m *tiled.Map, gid uint32) (*tiled.LayerTile, error) {
+	tile, err := m.TileGIDToTile(gid)
+	if err != nil || tile.Nil {
+		return tile, err
+	}
+	ts := tile.Tileset
+	if ts.Image == nil {
+		if _, err := ts.GetTilesetTile(tile.ID); err != nil {
+			return nil, fmt.Errorf("gid %d: %w", gid, tiled.ErrInvalidTileGID)
+		}
+	} else if ts.TileCount > 0 && tile.ID >= uint32(ts.TileCount) {
+		return nil, fmt.Errorf("gid %d: %w", gid, tiled.ErrInvalidTileGID)
+	}
+	return tile, nil
+}
+
+// revertTiles puts every edited tile back to how the TMX defined it.
+func (w *World) revertTiles() {
+	for key, orig := range w.tileOrig {
+		m := w.maps[key.mapName]
+		if layer := mapLayer(m, key.layer); layer != nil {
+			layer.Tiles[key.index] = orig
+			w.tileChanged(m, layer, key.index)
+		}
+	}
+	w.tileEdits = nil
+	w.tileOrig = nil
+	w.invalidateGrid()
+}
+
+// restoreTile undoes the edit of one tile, putting back how the map had it.
+func (w *World) restoreTile(mapName, layerName string, idx int) {
+	key := tileKey{mapName, layerName, idx}
+	orig, ok := w.tileOrig[key]
+	if !ok {
+		return
+	}
+	m := w.maps[mapName]
+	layer := mapLayer(m, layerName)
+	layer.Tiles[idx] = orig
+	delete(w.tileEdits, key)
+	delete(w.tileOrig, key)
+	w.tileChanged(m, layer, idx)
+}
+
+// tileChanged drops what was worked out from the old tile: the walk grid, its
+// light and anything the renderer cached through OnTileChange.
+func (w *World) tileChanged(m *tiled.Map, layer *tiled.Layer, idx int) {
+	w.invalidateGrid()
+	w.updateLight(m, layer, idx)
+	if w.OnTileChange != nil {
+		w.OnTileChange(m, layer, idx)
+	}
+}
+
+// TileEdits lists every tile changed since the map was loaded.
+func (w *World) TileEdits() []TileEdit {
+	edits := make([]TileEdit, 0, len(w.tileEdits))
+	for k, gid := range w.tileEdits {
+		edits = append(edits, TileEdit{Map: k.mapName, Layer: k.layer, Index: k.index, GID: gid})
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].Map != edits[j].Map {
+			return edits[i].Map < edits[j].Map
+		}
+		if edits[i].Layer != edits[j].Layer {
+			return edits[i].Layer < edits[j].Layer
+		}
+		return edits[i].Index < edits[j].Index
+	})
+	return edits
+}
+
+// Tiled keeps the flips of a tile in the top bits of its GID.
+const (
+	gidFlipH    = 0x80000000
+	gidFlipV    = 0x40000000
+	gidFlipD    = 0x20000000
+	gidFlipBits = gidFlipH | gidFlipV | gidFlipD
+)
+
+// layerTileGID is the global ID of a tile with its flip bits, 0 for an empty
+// cell.
+func layerTileGID(tile *tiled.LayerTile) uint32 {
+	if tile == nil || tile.Tileset == nil {
+		return 0
+	}
+	gid := tile.Tileset.FirstGID + tile.ID
+	if tile.HorizontalFlip {
+		gid |= gidFlipH
+	}
+	if tile.VerticalFlip {
+		gid |= gidFlipV
+	}
+	if tile.DiagonalFlip {
+		gid |= gidFlipD
+	}
+	return gid
+}
+
+func (w *World) layer(name string) *tiled.Layer {
+	return mapLayer(w.Map, name)
+}
+
+func mapLayer(m *tiled.Map, name string) *tiled.Layer {
+	if m == nil {
+		return nil
+	}
+	for _, layer := range m.Layers {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
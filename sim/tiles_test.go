@@ -0,0 +1,53 @@
+package sim
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+func TestGIDTile(t *testing.T) {
+	w := newTestWorld(t)
+	sheet := w.Map.Tilesets[0]
+	// A collection of images whose tiles were added 0, 1 and 5, after the
+	// ones in between were deleted
+	images := &tiled.Tileset{
+		FirstGID:  sheet.FirstGID + uint32(sheet.TileCount),
+		TileCount: 3,
+		Tiles:     []*tiled.TilesetTile{{ID: 0}, {ID: 1}, {ID: 5}},
+	}
+	m := &tiled.Map{Tilesets: []*tiled.Tileset{sheet, images}}
+
+	tests := []struct {
+		name string
+		gid  uint32
+		ok   bool
+	}{
+		{"empty", 0, true},
+		{"sheet", sheet.FirstGID + 462, true},
+		{"sheet, flipped", (sheet.FirstGID + 462) | gidFlipH, true},
+		{"sheet's last", sheet.FirstGID + uint32(sheet.TileCount) - 1, true},
+		{"collection", images.FirstGID + 1, true},
+		{"collection, past the count", images.FirstGID + 5, true},
+		{"collection gap", images.FirstGID + 2, false},
+		{"past the collection", images.FirstGID + 6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tile, err := gidTile(m, tt.gid)
+			if !tt.ok {
+				if !errors.Is(err, tiled.ErrInvalidTileGID) {
+					t.Errorf("gid %d: tile %+v, err %v, want ErrInvalidTileGID", tt.gid, tile, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := layerTileGID(tile); got != tt.gid {
+				t.Errorf("gid %d looked up as %d", tt.gid, got)
+			}
+		})
+	}
+}
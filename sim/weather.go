@@ -0,0 +1,201 @@
+package sim
+
+import "github.com/lafriks/go-tiled"
+
+// WeatherKind is the kind of sky outdoors.
+type WeatherKind int
+
+const (
+	Clear WeatherKind = iota
+	Rain
+	Storm
+	Snow
+	Fog
+)
+
+var weatherNames = [...]string{"clear", "rain", "storm", "snow", "fog"}
+
+func (k WeatherKind) String() string {
+	return weatherNames[k]
+}
+
+const (
+	weatherSpell = 3 * 60 // in-game minutes between changes of weather
+	weatherFade  = 60     // minutes a spell takes to set in or clear up
+	rainWetsAt   = 0.5    // intensity from which rain waters crops and puts out fires
+	fogClearView = 24.0   // visibility in tiles at the start of a fog
+	fogThickView = 4.0    // visibility in tiles in the thick of it
+	stormLonely  = 1.5    // extra social drain in a full storm, on top of the usual
+)
+
+// weatherNext holds the odds of what a spell of weather turns into next.
+// Storms only brew out of rain. In winter rain and storms fall as snow, and
+// snow falls as rain the rest of the year, see seasonalWeather.
+var weatherNext = map[WeatherKind][]struct {
+	kind   WeatherKind
+	weight int
+}{
+	Clear: {{Clear, 6}, {Rain, 2}, {Fog, 1}, {Snow, 1}},
+	Rain:  {{Rain, 3}, {Storm, 1}, {Clear, 3}, {Fog, 1}},
+	Storm: {{Storm, 1}, {Rain, 2}, {Clear, 1}},
+	Snow:  {{Snow, 3}, {Clear, 2}},
+	Fog:   {{Fog, 2}, {Clear, 3}, {Rain, 1}},
+}
+
+// Weather is the sky outdoors right now. Intensity rises from 0 to 1 while
+// a spell sets in and falls again before it gives way to another.
+//
+// Every run starts clear and the weather changes every few in-game hours
+// as a chain of weighted draws seeded by the world seed, so a save always
+// sees the same weather again without storing it.
+type Weather struct {
+	Kind      WeatherKind
+	Intensity float64
+}
+
+// weatherChain is where updateWeather last was in the weather chain, so
+// each minute steps it forward instead of replaying the whole run.
+type weatherChain struct {
+	seed       int64
+	spell      int
+	prev, kind WeatherKind // weather of spell-1 and spell
+}
+
+// chainTo moves the weather chain to the given spell. It starts over from
+// the clear sky of the first spell only when the seed changed or it has to
+// go back, e.g. after loading a save.
+func (w *World) chainTo(spell int) *weatherChain {
+	c := w.chain
+	if c == nil || c.seed != w.Seed || c.spell > spell {
+		c = &weatherChain{seed: w.Seed}
+		w.chain = c
+	}
+	for c.spell < spell {
+		c.spell++
+		c.prev, c.kind = c.kind, w.nextWeather(c.kind, c.spell)
+	}
+	return c
+}
+
+// nextWeather draws the weather of a spell from the one before it.
+func (w *World) nextWeather(kind WeatherKind, spell int) WeatherKind {
+	season := dateOf(w.dayAt(spell * weatherSpell)).Season
+	next := weatherNext[kind]
+	total := 0
+	for _, n := range next {
+		total += n.weight
+	}
+	r := int(hash2(w.Seed, spell, 0, saltWeather) % uint64(total))
+	for _, n := range next {
+		if r < n.weight {
+			return seasonalWeather(n.kind, season)
+		}
+		r -= n.weight
+	}
+	return kind
+}
+
+func seasonalWeather(kind WeatherKind, s Season) WeatherKind {
+	switch {
+	case s == Winter && (kind == Rain || kind == Storm):
+		return Snow
+	case s != Winter && kind == Snow:
+		return Rain
+	}
+	return kind
+}
+
+// updateWeather works out the weather for the current time of the run.
+func (w *World) updateWeather() {
+	t := w.Stats.MinutesSurvived
+	spell, into := t/weatherSpell, t%weatherSpell
+	c := w.chainTo(spell)
+	kind := c.kind
+	intensity := 1.0
+	if spell > 0 && c.prev != kind {
+		intensity = min(intensity, float64(into)/weatherFade)
+	}
+	if w.nextWeather(kind, spell+1) != kind {
+		intensity = min(intensity, float64(weatherSpell-into)/weatherFade)
+	}
+	w.Weather = Weather{Kind: kind, Intensity: intensity}
+}
+
+// stepWeather runs once per in-game minute: rain waters the crops and puts
+// out the unsheltered fires of every outdoor map.
+func (w *World) stepWeather() {
+	w.updateWeather()
+	if !w.raining() {
+		return
+	}
+	for _, plot := range w.plots {
+		if !plot.Watered && w.outdoorMap(plot.Map) {
+			w.wetPlot(plot)
+		}
+	}
+	for _, s := range w.structures {
+		if w.burning(s) && w.rainsOn(s) {
+			w.douse(s)
+		}
+	}
+}
+
+func (w *World) raining() bool {
+	return (w.Weather.Kind == Rain || w.Weather.Kind == Storm) && w.Weather.Intensity >= rainWetsAt
+}
+
+// socialDrain scales how fast the social bar drains: storms outside wear
+// the player down.
+func (w *World) socialDrain() float64 {
+	if w.Weather.Kind == Storm && w.Outdoors() {
+		return 1 + stormLonely*w.Weather.Intensity
+	}
+	return 1
+}
+
+// Visibility is how far the player can see outdoors in tiles, 0 when the
+// view is clear.
+func (w *World) Visibility() float64 {
+	if w.Weather.Kind != Fog || !w.Outdoors() {
+		return 0
+	}
+	return fogClearView + (fogThickView-fogClearView)*w.Weather.Intensity
+}
+
+// Outdoors reports whether the current map is under the sky. Maps with the
+// bool property indoors=true keep the weather out.
+func (w *World) Outdoors() bool {
+	return !isIndoors(w.Map)
+}
+
+func (w *World) outdoorMap(name string) bool {
+	m := w.maps[name]
+	return m != nil && !isIndoors(m)
+}
+
+// rainsOn reports whether rain falls on a structure: it stands on an
+// outdoor map with no roof over it.
+func (w *World) rainsOn(s *Structure) bool {
+	return w.outdoorMap(s.Map) && !w.sheltered(s)
+}
+
+// sheltered reports whether a tile flagged "above", such as a roof or an
+// awning, covers a structure from a visible layer stacked over it.
+func (w *World) sheltered(s *Structure) bool {
+	m := w.maps[s.Map]
+	over := false
+	for _, layer := range m.Layers {
+		if layer.Name == structuresLayer {
+			over = true
+			continue
+		}
+		if over && layer.Visible && DrawsAbove(layer, s.Index) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIndoors(m *tiled.Map) bool {
+	return m.Properties != nil && m.Properties.GetBool("indoors")
+}
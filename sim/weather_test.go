@@ -0,0 +1,76 @@
+package sim
+
+import "testing"
+
+// weatherAt works out the weather after a run started at 08:00 has lasted
+// the given number of minutes.
+func weatherAt(w *World, minutes int) Weather {
+	w.Stats.MinutesSurvived = minutes
+	w.GameMinutes = (8*60 + minutes) % MinutesPerDay
+	w.updateWeather()
+	return w.Weather
+}
+
+func TestWeatherSameSeed(t *testing.T) {
+	a, b := newTestWorld(t), newTestWorld(t)
+	if a.Seed != b.Seed {
+		t.Fatalf("seeds %d and %d from the same world seed", a.Seed, b.Seed)
+	}
+	minutes := []int{0, 59, 180, 239, 1000, 1440, 5000, 20000, 100000}
+	kinds := map[WeatherKind]bool{}
+	for _, m := range minutes {
+		kinds[weatherAt(a, m).Kind] = true
+	}
+	// Backwards, so b starts the chain over every time
+	for i := len(minutes) - 1; i >= 0; i-- {
+		m := minutes[i]
+		if got, want := weatherAt(b, m), weatherAt(a, m); got != want {
+			t.Errorf("minute %d: %v at %v, want %v at %v", m, got.Kind, got.Intensity, want.Kind, want.Intensity)
+		}
+	}
+	if len(kinds) < 2 {
+		t.Errorf("only %v over %d days", kinds, minutes[len(minutes)-1]/MinutesPerDay)
+	}
+}
+
+// rainyMinute finds a minute of the run when rain is heavy enough to put
+// out fires.
+func rainyMinute(t *testing.T, w *World) int {
+	t.Helper()
+	for m := 0; m < 60*MinutesPerDay; m++ {
+		weatherAt(w, m)
+		if w.raining() {
+			return m
+		}
+	}
+	t.Fatal("no rain in 60 days")
+	return 0
+}
+
+func TestWeatherDousesFires(t *testing.T) {
+	w := newTestWorld(t)
+	open := w.tileIndex(w.Player.Pos)
+	roofed := open + 1
+	if err := w.SetTile("Buildings", roofed, 1); err != nil {
+		t.Fatal(err)
+	}
+	fires := map[int]*Structure{}
+	w.structures = map[tileRef]*Structure{}
+	for _, idx := range []int{open, roofed} {
+		s := &Structure{Buildable: "campfire", Map: w.MapName, Index: idx, Fuel: 60}
+		w.structures[tileRef{w.MapName, idx}] = s
+		fires[idx] = s
+	}
+	if w.sheltered(fires[open]) || !w.sheltered(fires[roofed]) {
+		t.Fatalf("sheltered: open %v, roofed %v", w.sheltered(fires[open]), w.sheltered(fires[roofed]))
+	}
+
+	w.Stats.MinutesSurvived = rainyMinute(t, w)
+	w.stepWeather()
+	if w.burning(fires[open]) {
+		t.Error("rain left the open fire burning")
+	}
+	if !w.burning(fires[roofed]) {
+		t.Error("rain put out the fire under the roof")
+	}
+}
@@ -0,0 +1,266 @@
+// Package sim holds the survival rules of the game. A World is advanced one
+// fixed tick at a time from an Input snapshot and never touches ebiten or the
+// wall clock, so it can run headless in tests and CI containers.
+package sim
+
+import (
+	"image"
+	"math/rand"
+
+	"github.com/lafriks/go-tiled"
+)
+
+const (
+	TileSize       = 15 // Adjusted to match actual tile size
+	TicksPerSecond = 60 // Fixed simulation rate, matches ebiten's default TPS
+	MinutesPerDay  = 24 * 60
+
+	// 10 real min per game hour, so 10/60 real seconds per game minute
+	ticksPerGameMinute = TicksPerSecond * 10 / 60
+
+	inputDelayTicks  = TicksPerSecond / 5 // 200ms between repeated key actions
+	actionBlockTicks = TicksPerSecond     // 1s block after chats and actions
+)
+
+type NPC struct {
+	Pos      image.Point
+	Dir      int
+	Name     string
+	Sprite   string // path to the sprite sheet, resolved by the renderer
+	Anim     int
+	AnimTick int
+	Moving   bool
+	Away     bool        // off the map, e.g. home for the night; cannot be talked to
+	moveTick int         // for random movement timing
+	target   image.Point // target position for smooth movement
+	path     []TilePoint // remaining route to the schedule's destination
+	def      *NPCDef
+}
+
+type Player struct {
+	Pos      image.Point
+	Dir      int     // direction (0=down,1=left,2=right,3=up)
+	Anim     int     // animation frame (0-3)
+	AnimTick int     // animation tick
+	Moving   bool    // is player moving
+	moveAcc  float64 // fraction of a pixel carried over, see walk_speed
+}
+
+// Stats are counted over a whole run.
+type Stats struct {
+	MinutesSurvived int `json:"minutesSurvived"`
+	ItemsGathered   int `json:"itemsGathered"`
+}
+
+// DaysSurvived is the number of full in-game days the run has lasted.
+func (s Stats) DaysSurvived() int {
+	return s.MinutesSurvived / MinutesPerDay
+}
+
+// DeathSummary describes how a run ended.
+type DeathSummary struct {
+	Cause string
+	Stats Stats
+}
+
+type InventorySlot struct {
+	Item  string `json:"item,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+type World struct {
+	Map      *tiled.Map // map the player is on
+	MapName  string
+	StartMap string    // map every run starts on
+	LoadMap  MapLoader // loads other maps when the player goes through a portal
+	Seed     int64     // procedural maps are generated from this, new every run
+
+	// OnTileChange, if set, is called after a map tile changes so renderers
+	// can refresh whatever they cached about it.
+	OnTileChange func(m *tiled.Map, layer *tiled.Layer, idx int)
+
+	Player        Player
+	NPCs          []*NPC    // NPCs on the current map
+	NPCDefs       []*NPCDef // who lives here and their daily schedules
+	Inventory     [8][8]InventorySlot
+	InventoryOpen bool
+	Items         map[string]*ItemDef // item registry keyed by ID
+	Recipes       []*Recipe           // crafting and cooking recipes
+	Resources     []*ResourceDef      // harvestable trees, bushes and rocks
+	LootTables    map[string]*LootTable
+	Farm          *FarmDef
+	Buildables    []*Buildable
+	Fishing       *Fishing  // attempt in progress, nil when not fishing
+	CraftChoice   int       // highlighted recipe in the inventory action panel
+	Crafting      *CraftJob // recipe in progress, nil when idle
+	GameOver      bool
+
+	Chatting      bool
+	ChatNPC       *NPC
+	ChatChoice    int                          // index of the highlighted choice
+	ConvNode      *ConversationNode            // current node in conversation
+	Conversations map[string]*ConversationNode // map NPC or interaction name to root conversation node
+	Flags         map[string]bool              // story flags set by dialogue effects
+
+	Shops        map[string]*Shop // map NPC name to their shop
+	Trading      *Shop            // shop whose trade window is open
+	TradeChoice  int              // highlighted line in the trade window
+	TradeSelling bool             // selling to the shop instead of buying
+	TradeMsg     string           // result of the last trade
+
+	Building *BuildMode // open while placing structures, nil otherwise
+
+	// Status bars and time
+	Health      float64 // 0.0 - 1.0
+	Social      float64 // 0.0 - 1.0
+	Hunger      float64 // 0.0 - 1.0
+	GameMinutes int     // 0 - 1439 (24*60)
+	Weather     Weather
+
+	Tick int // ticks elapsed since the world was created
+
+	Stats Stats         // run statistics for the game over summary
+	Death *DeathSummary // set when the run ends
+
+	AutosaveEvery int // in-game minutes between autosaves, 0 disables
+
+	target           *interactTarget    // tile the current interaction started from
+	tileEdits        map[tileKey]uint32 // runtime tile changes, see SetTile
+	tileOrig         map[tileKey]*tiled.LayerTile
+	lights           map[*tiled.Map]map[lightKey]Light // lit tiles of each map seen, see Lights
+	minutesSinceSave int
+	autosaveDue      bool
+	diedAt           int // tick of death, restart input is ignored briefly after
+	minuteTicks      int // ticks accumulated toward the next game minute
+	lastChatEnd      int // tick when the last chat ended
+	lastChoice       int // tick of the last chat input
+	lastInventory    int // tick of the last inventory open/close/action
+	rng              *rand.Rand
+	maps             map[string]*tiled.Map // every map loaded so far
+	npcsByMap        map[string][]*NPC
+	everyNPC         []*NPC                          // NPCs of all maps in spawn order
+	pickedUp         map[string]bool                 // pickups collected this run, see objectKey
+	inTriggers       map[string]bool                 // trigger zones the player is standing in
+	grid             *Grid                           // cached walkability, nil after a tile changes
+	generated        map[string]map[image.Point]bool // chunks of procedural maps filled in so far
+	regrowing        []*Regrowth                     // harvested resource nodes, see harvest
+	chain            *weatherChain                   // last spell of weather worked out, see updateWeather
+	plots            map[tileRef]*Plot               // tilled tiles of every map
+	structures       map[tileRef]*Structure          // placed buildables of every map
+	lastBuild        int                             // tick of the last build mode action
+	notice           string                          // message for the player, see TakeNotice
+	lastUse          int                             // tick of the last farming action
+}
+
+// NewWorld creates a world starting on the named map with the player at the
+// map centre.
+func NewWorld(name string, m *tiled.Map, seed int64) *World {
+	w := &World{
+		Map:      m,
+		MapName:  name,
+		StartMap: name,
+		rng:      rand.New(rand.NewSource(seed)),
+		maps:     map[string]*tiled.Map{name: m},
+	}
+	w.Reset()
+	return w
+}
+
+// Reset starts a fresh run: the player at the spawn point of the start map
+// with an empty inventory and full bars at 08:00, NPCs at their spawns, every
+// map tile back to how the TMX defined it and a new seed for procedural maps.
+func (w *World) Reset() {
+	w.Map, w.MapName = w.maps[w.StartMap], w.StartMap
+	w.Player = Player{Pos: w.playerSpawn()}
+	w.Inventory = [8][8]InventorySlot{}
+	w.Health = 1.0
+	w.Social = 1.0
+	w.Hunger = 1.0
+	w.GameMinutes = 8 * 60 // Start at 08:00
+	w.minuteTicks = 0
+	w.Stats = Stats{}
+	w.Death = nil
+	w.GameOver = false
+	w.Chatting = false
+	w.ChatNPC = nil
+	w.ConvNode = nil
+	w.target = nil
+	w.Flags = nil
+	w.pickedUp = map[string]bool{}
+	w.inTriggers = nil
+	w.InventoryOpen = false
+	w.Crafting = nil
+	w.Trading = nil
+	w.Fishing = nil
+	w.Building = nil
+	w.notice = ""
+	for _, shop := range w.Shops {
+		shop.Restock()
+	}
+	w.lastChatEnd = w.Tick // don't let the restart key open anything
+	w.revertTiles()
+	w.regrowing = nil
+	w.plots = nil
+	w.structures = nil
+	w.Seed = w.rng.Int63()
+	w.clearGenerated()
+	w.updateWeather()
+	w.SpawnNPCs()
+}
+
+// Validate checks the loaded game data for references that lead nowhere:
+// unknown items, portals to missing maps or spawn points, map objects naming
+// missing dialogues, NPCs placed on no map, missing village prefabs and loot
+// tables, and crops or catches that aren't registered items. Every
+// map reachable through a portal is loaded on the way.
+func (w *World) Validate() error {
+	if err := w.validateItems(); err != nil {
+		return err
+	}
+	if err := w.validatePortals(); err != nil {
+		return err
+	}
+	if err := w.validateProcedural(); err != nil {
+		return err
+	}
+	if err := w.validateLoot(); err != nil {
+		return err
+	}
+	if err := w.validateFarm(); err != nil {
+		return err
+	}
+	if err := w.validateBuildables(); err != nil {
+		return err
+	}
+	return w.validateObjects()
+}
+
+func (w *World) mapCenter() image.Point {
+	return image.Point{
+		X: (w.Map.Width*TileSize - TileSize) / 2,
+		Y: (w.Map.Height*TileSize - TileSize) / 2,
+	}
+}
+
+// SpawnNPCs places every NPC at its npc object on its map.
+func (w *World) SpawnNPCs() {
+	w.npcsByMap = map[string][]*NPC{}
+	w.everyNPC = nil
+	for _, def := range w.npcSpawns() {
+		if !def.placed {
+			continue
+		}
+		npc := &NPC{
+			Pos:    def.start.pos(),
+			Dir:    0,
+			Name:   def.Name,
+			Sprite: def.Sprite,
+			def:    def,
+		}
+		// Anyone whose schedule has them home right now starts off the map
+		npc.Away = def.Activity(w.GameMinutes).Activity == "home"
+		w.npcsByMap[def.onMap] = append(w.npcsByMap[def.onMap], npc)
+		w.everyNPC = append(w.everyNPC, npc)
+	}
+	w.NPCs = w.npcsByMap[w.MapName]
+}
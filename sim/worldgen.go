@@ -0,0 +1,342 @@
+package sim
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// A map with the bool property procedural=true is a template: its tile layers
+// start empty and are filled with terrain chunk by chunk as the player gets
+// near. Every tile is a pure function of the world seed and its position, so
+// chunks come out the same whatever order they are generated in, and a save
+// only needs the seed plus the usual tile edits to rebuild the world.
+//
+// Generation writes to the layers the rest of the game already knows:
+//
+//	Base ground  grass with the odd flower bed
+//	Water        lakes; collides and fish come from the template's layer
+//	Trees        forests of two-tile trees
+//	Resources    berry bushes and rocks scattered over open ground
+//	Crops        left empty for the player's fields
+//	Buildings, Windows, Doors
+//	             villages of houses copied from the map named by the
+//	             template's village_prefab property
+//
+// The template's own objects (portals, spawn points) get a clearing so the
+// player never arrives inside a lake or a tree.
+
+const (
+	genChunk       = 16 // chunk side in tiles
+	genRadius      = 2  // chunks generated around the player's chunk
+	villageRegion  = 64 // tiles per side of an area holding at most one village
+	villageChance  = 0.5
+	villageClear   = 20 // half-size of the clearing around a village centre
+	objectClear    = 4  // half-size of the clearing around template objects
+	waterLevel     = 0.32
+	forestLevel    = 0.55
+	treeDensity    = 0.7
+	flowerDensity  = 0.02
+	bushDensity    = 0.004
+	rockDensity    = 0.004
+	houseSpacingX  = 14
+	houseSpacingY  = 14
+	maxVillageSize = 4
+)
+
+// GIDs of the roguelike sheet, which procedural templates must use with
+// firstgid 1.
+const (
+	gidGrass     = 628
+	gidWater     = 228
+	gidBerryBush = 529
+	gidRock      = 1231
+)
+
+var (
+	gidFlowers = []uint32{396, 564, 732}
+	gidTrees   = [][2]uint32{{579, 635}, {574, 630}, {576, 632}, {575, 631}} // top, bottom
+)
+
+// Salts keep the noise fields independent of each other.
+const (
+	saltWater = iota + 1
+	saltForest
+	saltTree
+	saltTreeKind
+	saltFlower
+	saltResource
+	saltVillage
+	saltVillageLayout
+	saltWeather
+)
+
+func isProcedural(m *tiled.Map) bool {
+	return m != nil && m.Properties != nil && m.Properties.GetBool("procedural")
+}
+
+// generateAround fills in the chunks around the player on a procedural map.
+func (w *World) generateAround() {
+	if !isProcedural(w.Map) {
+		return
+	}
+	c := image.Point{X: w.Player.Pos.X / TileSize / genChunk, Y: w.Player.Pos.Y / TileSize / genChunk}
+	for cy := c.Y - genRadius; cy <= c.Y+genRadius; cy++ {
+		for cx := c.X - genRadius; cx <= c.X+genRadius; cx++ {
+			w.generateChunk(w.MapName, w.Map, image.Point{X: cx, Y: cy})
+		}
+	}
+}
+
+// generateChunk fills one chunk of a procedural map unless it already has
+// been this run. Tiles the player has edited keep their edit.
+func (w *World) generateChunk(name string, m *tiled.Map, c image.Point) {
+	x0, y0 := c.X*genChunk, c.Y*genChunk
+	if x0 < 0 || y0 < 0 || x0 >= m.Width || y0 >= m.Height || w.generated[name][c] {
+		return
+	}
+	if w.generated == nil {
+		w.generated = map[string]map[image.Point]bool{}
+	}
+	if w.generated[name] == nil {
+		w.generated[name] = map[image.Point]bool{}
+	}
+	w.generated[name][c] = true
+
+	gen := w.terrain(m, c)
+	for y := y0; y < min(y0+genChunk, m.Height); y++ {
+		for x := x0; x < min(x0+genChunk, m.Width); x++ {
+			for _, layer := range m.Layers {
+				w.placeGenerated(name, m, layer, y*m.Width+x, gen.tile(layer.Name, x, y))
+			}
+		}
+	}
+}
+
+func (w *World) placeGenerated(name string, m *tiled.Map, layer *tiled.Layer, idx int, gid uint32) {
+	tile, err := m.TileGIDToTile(gid)
+	if err != nil {
+		tile = tiled.NilLayerTile
+	}
+	key := tileKey{name, layer.Name, idx}
+	if _, edited := w.tileEdits[key]; edited {
+		w.tileOrig[key] = tile // reverting the edit brings back the terrain
+		return
+	}
+	layer.Tiles[idx] = tile
+	w.tileChanged(m, layer, idx)
+}
+
+// clearGenerated empties every procedural map so it is generated afresh,
+// e.g. from a new seed.
+func (w *World) clearGenerated() {
+	for name, chunks := range w.generated {
+		m := w.maps[name]
+		for c := range chunks {
+			for y := c.Y * genChunk; y < min((c.Y+1)*genChunk, m.Height); y++ {
+				for x := c.X * genChunk; x < min((c.X+1)*genChunk, m.Width); x++ {
+					for _, layer := range m.Layers {
+						layer.Tiles[y*m.Width+x] = tiled.NilLayerTile
+						w.tileChanged(m, layer, y*m.Width+x)
+					}
+				}
+			}
+		}
+	}
+	w.generated = nil
+}
+
+// validateProcedural checks that the village prefab of every loaded
+// procedural map loads.
+func (w *World) validateProcedural() error {
+	for name, m := range w.maps {
+		if !isProcedural(m) {
+			continue
+		}
+		if prefab := m.Properties.GetString("village_prefab"); prefab != "" {
+			if _, err := w.mapByName(prefab); err != nil {
+				return fmt.Errorf("village prefab of map %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// terrainGen decides the tiles of one chunk.
+type terrainGen struct {
+	seed      int64
+	prefab    *tiled.Map
+	clearings []image.Rectangle // no water or trees in here
+	houses    []image.Point     // top-left tile of each prefab house
+}
+
+// terrain prepares generation of chunk c: the villages and clearings that
+// can reach into it.
+func (w *World) terrain(m *tiled.Map, c image.Point) *terrainGen {
+	g := &terrainGen{seed: w.Seed}
+	if name := m.Properties.GetString("village_prefab"); name != "" {
+		g.prefab, _ = w.mapByName(name) // Validate reports a missing prefab
+	}
+	for _, group := range m.ObjectGroups {
+		for _, obj := range group.Objects {
+			t := objectTile(obj)
+			g.clearings = append(g.clearings, image.Rect(t.X-objectClear, t.Y-objectClear, t.X+objectClear+1, t.Y+objectClear+1))
+		}
+	}
+	rx, ry := c.X*genChunk/villageRegion, c.Y*genChunk/villageRegion
+	for vy := ry - 1; vy <= ry+1; vy++ {
+		for vx := rx - 1; vx <= rx+1; vx++ {
+			centre, houses := g.village(vx, vy)
+			if houses == 0 {
+				continue
+			}
+			g.clearings = append(g.clearings, image.Rect(centre.X-villageClear, centre.Y-villageClear, centre.X+villageClear+1, centre.Y+villageClear+1))
+			if g.prefab == nil {
+				continue
+			}
+			for i := range houses {
+				at := image.Point{
+					X: centre.X + (i%2)*houseSpacingX - houseSpacingX/2 - g.prefab.Width/2,
+					Y: centre.Y + (i/2)*houseSpacingY - houseSpacingY/2 - g.prefab.Height/2,
+				}
+				if at.X >= 0 && at.Y >= 0 && at.X+g.prefab.Width <= m.Width && at.Y+g.prefab.Height <= m.Height {
+					g.houses = append(g.houses, at)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// village returns the centre of the village in region (vx, vy) and how many
+// houses it has, 0 if the region has none.
+func (g *terrainGen) village(vx, vy int) (image.Point, int) {
+	if vx < 0 || vy < 0 || unit(hash2(g.seed, vx, vy, saltVillage)) >= villageChance {
+		return image.Point{}, 0
+	}
+	h := hash2(g.seed, vx, vy, saltVillageLayout)
+	margin := villageClear
+	centre := image.Point{
+		X: vx*villageRegion + margin + int(h%uint64(villageRegion-2*margin)),
+		Y: vy*villageRegion + margin + int(h>>32%uint64(villageRegion-2*margin)),
+	}
+	return centre, 2 + int(h>>16%uint64(maxVillageSize-1))
+}
+
+func (g *terrainGen) cleared(x, y int) bool {
+	p := image.Point{X: x, Y: y}
+	for _, r := range g.clearings {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *terrainGen) water(x, y int) bool {
+	return !g.cleared(x, y) && fbm(g.seed, saltWater, float64(x), float64(y), 32) < waterLevel
+}
+
+// treeAt reports whether a tree stands with its top at (x, y). Trees take
+// two tiles and only start on even rows so they never overlap.
+func (g *terrainGen) treeAt(x, y int) bool {
+	if y%2 != 0 || g.cleared(x, y) || g.cleared(x, y+1) || g.water(x, y) || g.water(x, y+1) {
+		return false
+	}
+	return fbm(g.seed, saltForest, float64(x), float64(y), 20) > forestLevel &&
+		unit(hash2(g.seed, x, y, saltTree)) < treeDensity
+}
+
+// tile returns the GID generated for a layer at (x, y), 0 for none.
+func (g *terrainGen) tile(layer string, x, y int) uint32 {
+	for _, at := range g.houses {
+		if x >= at.X && y >= at.Y && x < at.X+g.prefab.Width && y < at.Y+g.prefab.Height {
+			return prefabGID(g.prefab, layer, x-at.X, y-at.Y)
+		}
+	}
+	switch layer {
+	case "Base ground":
+		if h := hash2(g.seed, x, y, saltFlower); unit(h) < flowerDensity {
+			return gidFlowers[h%uint64(len(gidFlowers))]
+		}
+		return gidGrass
+	case "Water":
+		if g.water(x, y) {
+			return gidWater
+		}
+	case "Trees":
+		top := y - y%2
+		if g.treeAt(x, top) {
+			kind := gidTrees[hash2(g.seed, x, top, saltTreeKind)%uint64(len(gidTrees))]
+			return kind[y-top]
+		}
+	case "Resources":
+		if g.cleared(x, y) || g.water(x, y) || g.treeAt(x, y-y%2) {
+			return 0
+		}
+		switch r := unit(hash2(g.seed, x, y, saltResource)); {
+		case r < bushDensity:
+			return gidBerryBush
+		case r < bushDensity+rockDensity:
+			return gidRock
+		}
+	}
+	return 0
+}
+
+// prefabGID reads the GID at (x, y) of a prefab layer. Houses stand on grass,
+// so a prefab without a Base ground layer gets it filled in.
+func prefabGID(prefab *tiled.Map, layer string, x, y int) uint32 {
+	l := mapLayer(prefab, layer)
+	if l == nil {
+		if layer == "Base ground" {
+			return gidGrass
+		}
+		return 0
+	}
+	return layerTileGID(l.Tiles[y*prefab.Width+x])
+}
+
+// hash2 mixes a position and salt into 64 random-looking bits (splitmix64).
+func hash2(seed int64, x, y, salt int) uint64 {
+	h := uint64(seed) ^ uint64(x)*0x9E3779B97F4A7C15 ^ uint64(y)*0xC2B2AE3D27D4EB4F ^ uint64(salt)*0x165667B19E3779F9
+	h ^= h >> 30
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 27
+	h *= 0x94D049BB133111EB
+	h ^= h >> 31
+	return h
+}
+
+// unit maps a hash to [0, 1).
+func unit(h uint64) float64 {
+	return float64(h>>11) / (1 << 53)
+}
+
+// valueNoise is smooth noise in [0, 1) with features about one unit apart.
+func valueNoise(seed int64, salt int, x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	fx, fy = fx*fx*(3-2*fx), fy*fy*(3-2*fy)
+	ix, iy := int(x0), int(y0)
+	v := func(dx, dy int) float64 { return unit(hash2(seed, ix+dx, iy+dy, salt)) }
+	top := v(0, 0) + (v(1, 0)-v(0, 0))*fx
+	bottom := v(0, 1) + (v(1, 1)-v(0, 1))*fx
+	return top + (bottom-top)*fy
+}
+
+// fbm layers three octaves of value noise, the largest features about size
+// tiles across.
+func fbm(seed int64, salt int, x, y, size float64) float64 {
+	var sum, norm float64
+	amp := 1.0
+	for octave := range 3 {
+		sum += amp * valueNoise(seed, salt*8+octave, x/size, y/size)
+		norm += amp
+		amp /= 2
+		size /= 2
+	}
+	return sum / norm
+}
@@ -0,0 +1,119 @@
+package sim
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// generateWilds fills every chunk of the wilds map in the given order and
+// returns the map with the village houses each chunk placed.
+func generateWilds(t *testing.T, w *World, order []image.Point) (*tiled.Map, map[image.Point][]image.Point) {
+	t.Helper()
+	m, err := w.mapByName("wilds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	houses := map[image.Point][]image.Point{}
+	for _, c := range order {
+		w.generateChunk("wilds", m, c)
+		houses[c] = w.terrain(m, c).houses
+	}
+	return m, houses
+}
+
+func TestWorldgenSameSeed(t *testing.T) {
+	a, b := newTestWorld(t), newTestWorld(t)
+	b.Seed = a.Seed
+
+	var order []image.Point
+	for cy := range 256 / genChunk {
+		for cx := range 256 / genChunk {
+			order = append(order, image.Point{X: cx, Y: cy})
+		}
+	}
+	shuffled := append([]image.Point{}, order...)
+	rand.New(rand.NewSource(7)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	ma, housesA := generateWilds(t, a, order)
+	mb, housesB := generateWilds(t, b, shuffled)
+
+	placed := 0
+	for _, c := range order {
+		ha, hb := housesA[c], housesB[c]
+		if len(ha) != len(hb) {
+			t.Fatalf("chunk %v: %d houses, then %d", c, len(ha), len(hb))
+		}
+		for i := range ha {
+			if ha[i] != hb[i] {
+				t.Errorf("chunk %v: house %d at %v, then %v", c, i, ha[i], hb[i])
+			}
+		}
+		placed += len(ha)
+	}
+	if placed == 0 {
+		t.Error("no village houses placed, pick a seed with a village")
+	}
+
+	for i, la := range ma.Layers {
+		lb := mb.Layers[i]
+		for idx := range la.Tiles {
+			if ga, gb := layerTileGID(la.Tiles[idx]), layerTileGID(lb.Tiles[idx]); ga != gb {
+				t.Fatalf("%s tile %d: GID %d, then %d", la.Name, idx, ga, gb)
+			}
+		}
+	}
+
+	// A different seed makes a different world
+	b.Seed++
+	b.clearGenerated()
+	mb, _ = generateWilds(t, b, order)
+	water := mapLayer(ma, "Water")
+	for idx := range water.Tiles {
+		if layerTileGID(water.Tiles[idx]) != layerTileGID(mapLayer(mb, "Water").Tiles[idx]) {
+			return
+		}
+	}
+	t.Error("the next seed made the same lakes")
+}
+
+func TestPrefabKeepsFlips(t *testing.T) {
+	w := newTestWorld(t)
+	prefab, err := w.mapByName("village_house")
+	if err != nil {
+		t.Fatal(err)
+	}
+	walls := mapLayer(prefab, "Buildings")
+	idx := -1
+	for i := range walls.Tiles {
+		if hasTile(walls, i) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		t.Fatal("empty village house")
+	}
+	for _, flips := range []uint32{0, gidFlipH, gidFlipV, gidFlipD, gidFlipBits} {
+		orig := *walls.Tiles[idx]
+		orig.HorizontalFlip = flips&gidFlipH != 0
+		orig.VerticalFlip = flips&gidFlipV != 0
+		orig.DiagonalFlip = flips&gidFlipD != 0
+		walls.Tiles[idx] = &orig
+
+		gid := prefabGID(prefab, "Buildings", idx%prefab.Width, idx/prefab.Width)
+		if gid&gidFlipBits != flips {
+			t.Errorf("flips %#x: GID %#x", flips, gid)
+		}
+		tile, err := gidTile(prefab, gid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tile.ID != orig.ID || tile.HorizontalFlip != orig.HorizontalFlip || tile.VerticalFlip != orig.VerticalFlip || tile.DiagonalFlip != orig.DiagonalFlip {
+			t.Errorf("flips %#x: stamped %+v, want %+v", flips, *tile, orig)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jmainguy/survival-game/sim"
+)
+
+// titleMenu is shown before play starts: start a new game or load a slot.
+type titleMenu struct {
+	slots  []string
+	choice int // 0 = New Game, i > 0 = slots[i-1]
+	err    string
+}
+
+func newTitleMenu(saveDir string) *titleMenu {
+	slots, err := sim.ListSlots(saveDir)
+	if err != nil {
+		log.Printf("failed to list saves in %s: %v", saveDir, err)
+	}
+	return &titleMenu{slots: slots}
+}
+
+func (g *Game) updateTitle() {
+	t := g.title
+	options := len(t.slots) + 1
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		t.choice = (t.choice + options - 1) % options
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		t.choice = (t.choice + 1) % options
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyEnter) && !inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		return
+	}
+	if t.choice == 0 {
+		g.title = nil
+		return
+	}
+	slot := t.slots[t.choice-1]
+	if err := g.loadSlot(slot); err != nil {
+		t.err = err.Error()
+		return
+	}
+	g.title = nil
+}
+
+// loadSlot restores a save into the world and makes it the current slot.
+func (g *Game) loadSlot(slot string) error {
+	data, err := sim.LoadSlot(g.saveDir, slot)
+	if err != nil {
+		return err
+	}
+	if err := g.world.Restore(data); err != nil {
+		return err
+	}
+	if slot != sim.AutosaveSlot {
+		g.slot = slot
+	}
+	return nil
+}
+
+func (g *Game) drawTitle(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{20, 30, 20, 255})
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	x, y := w/2-80, h/3
+	ebitenutil.DebugPrintAt(screen, "SURVIVAL GAME", x, y)
+	options := append([]string{"New Game"}, g.title.slots...)
+	for i, opt := range options {
+		prefix := "  "
+		if i == g.title.choice {
+			prefix = "> "
+		}
+		if i > 0 {
+			opt = "Load " + opt
+		}
+		ebitenutil.DebugPrintAt(screen, prefix+opt, x, y+30+i*20)
+	}
+	if g.title.err != "" {
+		ebitenutil.DebugPrintAt(screen, g.title.err, x, y+30+len(options)*20+20)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/jmainguy/survival-game/sim"
+)
+
+// Particles over the viewport at full intensity.
+const (
+	maxRainDrops  = 400
+	maxStormDrops = 700
+	maxSnowFlakes = 300
+)
+
+// weatherFX is what the renderer keeps for the weather: particles drawn
+// over the viewport, the fog, the fading flash of the last lightning bolt and the
+// ambient sound loops.
+type weatherFX struct {
+	particles []particle
+	flash     float64
+	fog       *ebiten.Image // mist with a clearing around the player
+	rain      *audio.Player
+	wind      *audio.Player
+}
+
+// particle is a raindrop or snowflake in screen pixels.
+type particle struct {
+	x, y, speed float64
+}
+
+// updateWeather moves the particles and sets the ambient loops to the
+// world's weather. Nothing falls indoors, where it is only heard faintly.
+func (g *Game) updateWeather() {
+	fx := &g.weather
+	wx := g.world.Weather
+	outdoors := g.world.Outdoors()
+	w, h := float64(g.viewW), float64(g.viewH)
+
+	want := 0
+	if outdoors {
+		switch wx.Kind {
+		case sim.Rain:
+			want = int(maxRainDrops * wx.Intensity)
+		case sim.Storm:
+			want = int(maxStormDrops * wx.Intensity)
+		case sim.Snow:
+			want = int(maxSnowFlakes * wx.Intensity)
+		}
+	}
+	for len(fx.particles) < want {
+		fx.particles = append(fx.particles, particle{x: rand.Float64() * w, y: rand.Float64() * h, speed: 0.6 + rand.Float64()*0.4})
+	}
+	fx.particles = fx.particles[:want]
+	windX, fallY := weatherDrift(wx.Kind)
+	for i := range fx.particles {
+		p := &fx.particles[i]
+		p.x += windX * p.speed
+		p.y += fallY * p.speed
+		if wx.Kind == sim.Snow {
+			p.x += 0.3 * math.Sin(p.y/40+p.speed*10)
+		}
+		if p.y > h {
+			p.x, p.y = rand.Float64()*(w+100), -10
+		}
+		if p.x < -20 {
+			p.x += w + 40
+		}
+	}
+
+	fx.flash *= 0.85
+	if outdoors && wx.Kind == sim.Storm && rand.Float64() < 0.003*wx.Intensity {
+		fx.flash = 1
+	}
+
+	rain, wind := 0.0, 0.0
+	switch wx.Kind {
+	case sim.Rain:
+		rain = 0.5 * wx.Intensity
+	case sim.Storm:
+		rain, wind = 0.8*wx.Intensity, 0.6*wx.Intensity
+	case sim.Snow:
+		wind = 0.25 * wx.Intensity
+	case sim.Fog:
+		wind = 0.1 * wx.Intensity
+	}
+	if !outdoors {
+		rain, wind = rain*0.3, wind*0.3
+	}
+	fx.rain = g.ambientLoop(fx.rain, &noiseLoop{smooth: 0.3, gain: 0.6}, rain)
+	fx.wind = g.ambientLoop(fx.wind, &noiseLoop{smooth: 0.995, gain: 6, gust: 0.15}, wind)
+}
+
+// weatherDrift is how far particles move per tick, sideways and down.
+func weatherDrift(kind sim.WeatherKind) (float64, float64) {
+	switch kind {
+	case sim.Storm:
+		return -5, 14
+	case sim.Snow:
+		return -0.4, 1.2
+	}
+	return -1.5, 10
+}
+
+// ambientLoop starts a looping player on first use and sets its volume,
+// pausing it while silent.
+func (g *Game) ambientLoop(p *audio.Player, src *noiseLoop, volume float64) *audio.Player {
+	if p == nil {
+		if volume == 0 || g.audioContext == nil {
+			return nil
+		}
+		var err error
+		if p, err = g.audioContext.NewPlayer(src); err != nil {
+			return nil
+		}
+	}
+	p.SetVolume(volume)
+	switch {
+	case volume == 0 && p.IsPlaying():
+		p.Pause()
+	case volume > 0 && !p.IsPlaying():
+		p.Play()
+	}
+	return p
+}
+
+// drawWeather draws falling rain or snow, the fog closing in around the
+// player and lightning flashes over the scene.
+func (g *Game) drawWeather(screen *ebiten.Image, camX, camY int) {
+	wx := g.world.Weather
+	windX, fallY := weatherDrift(wx.Kind)
+	for _, p := range g.weather.particles {
+		if wx.Kind == sim.Snow {
+			vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), float32(1+p.speed), color.RGBA{240, 240, 255, 220}, true)
+			continue
+		}
+		vector.StrokeLine(screen, float32(p.x), float32(p.y), float32(p.x+windX), float32(p.y+fallY), 1, color.RGBA{150, 170, 210, 150}, true)
+	}
+	if view := g.world.Visibility(); view > 0 {
+		g.drawFog(screen, view, wx.Intensity, camX, camY)
+	}
+	if g.weather.flash > 0.02 {
+		a := uint8(0.7 * 255 * g.weather.flash)
+		vector.DrawFilledRect(screen, 0, 0, float32(screen.Bounds().Dx()), float32(screen.Bounds().Dy()), color.RGBA{a, a, a, a}, false)
+	}
+}
+
+// drawFog covers the scene in grey mist except for a clearing of view
+// tiles around the player.
+func (g *Game) drawFog(screen *ebiten.Image, view, intensity float64, camX, camY int) {
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	if g.weather.fog == nil || g.weather.fog.Bounds().Dx() != w || g.weather.fog.Bounds().Dy() != h {
+		g.weather.fog = ebiten.NewImage(w, h)
+	}
+	fog := g.weather.fog
+	a := 230 * intensity
+	fog.Fill(color.RGBA{uint8(190 / 255.0 * a), uint8(195 / 255.0 * a), uint8(200 / 255.0 * a), uint8(a)})
+	r := view * tileSize * scale
+	op := &ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationOut}
+	op.GeoM.Translate(-lightMaskSize/2, -lightMaskSize/2)
+	op.GeoM.Scale(2*r/lightMaskSize, 2*r/lightMaskSize)
+	op.GeoM.Translate(float64(g.world.Player.Pos.X*scale-camX+tileSize*scale/2), float64(g.world.Player.Pos.Y*scale-camY+tileSize*scale/2))
+	fog.DrawImage(g.lightMaskImage(), op)
+	screen.DrawImage(fog, nil)
+}
+
+// weatherDim darkens the daylight colour under clouds while outdoors.
+func weatherDim(c color.RGBA, w *sim.World) color.RGBA {
+	if !w.Outdoors() {
+		return c
+	}
+	dim := 0.0
+	switch w.Weather.Kind {
+	case sim.Rain:
+		dim = 0.2
+	case sim.Storm:
+		dim = 0.4
+	case sim.Snow:
+		dim = 0.1
+	case sim.Fog:
+		dim = 0.15
+	}
+	f := 1 - dim*w.Weather.Intensity
+	return color.RGBA{uint8(float64(c.R) * f), uint8(float64(c.G) * f), uint8(float64(c.B) * f), 255}
+}
+
+// noiseLoop is an endless 16-bit stereo stream of filtered noise for the
+// weather loops: a little smoothing gives the hiss of rain, a lot the rumble
+// of wind, and gust slowly swells and fades the volume.
+type noiseLoop struct {
+	smooth float64
+	gain   float64
+	gust   float64
+
+	last  float64
+	phase float64
+}
+
+func (n *noiseLoop) Read(p []byte) (int, error) {
+	const gustHz = 0.15
+	for i := 0; i+4 <= len(p); i += 4 {
+		n.last = n.last*n.smooth + (rand.Float64()*2-1)*(1-n.smooth)
+		n.phase += 2 * math.Pi * gustHz / sampleRate
+		v := n.last * n.gain * (1 - n.gust + n.gust*math.Sin(n.phase))
+		s := int16(max(-1, min(1, v)) * 0.3 * math.MaxInt16)
+		p[i], p[i+1] = byte(s), byte(s>>8)
+		p[i+2], p[i+3] = byte(s), byte(s>>8)
+	}
+	return len(p) / 4 * 4, nil
+}